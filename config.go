@@ -1,10 +1,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"sync"
 
 	"github.com/creasty/defaults"
+	"github.com/rottendev/config/pkg"
 	"github.com/rottendev/config/provider"
 )
 
@@ -14,49 +18,137 @@ type Provider interface {
 	Encode(v any) ([]byte, error)
 }
 
+// StrictProvider is implemented by providers able to reject keys without a matching struct field.
+type StrictProvider interface {
+	DecodeStrict(data []byte, v interface{}) error
+}
+
+// CommentedEncoder is implemented by providers able to document the fields of sample files
+// with comments, see pkg.FieldComment.
+type CommentedEncoder interface {
+	EncodeCommented(v any) ([]byte, error)
+}
+
+// UnknownFieldsError lists the keys rejected by strict decoding.
+type UnknownFieldsError = provider.UnknownFieldsError
+
+// UnknownField is a key rejected by strict decoding.
+type UnknownField = provider.UnknownField
+
+// InterpolationError is returned when a reference in a string value cannot be resolved.
+type InterpolationError = pkg.InterpolationError
+
+// UnsetVariablesError lists the template variables without a value nor a default.
+type UnsetVariablesError = pkg.UnsetVariablesError
+
 type Config struct {
-	cfgType      Type              // The configuration type.
-	providers    map[Type]Provider // The configuration providers.
-	filename     string            // The configuration filename.
-	parsedConfig interface{}
+	cfgType         Type                      // The configuration type.
+	providers       map[Type]Provider         // The configuration providers.
+	filename        string                    // The configuration filename.
+	layers          []layer                   // The configuration sources in precedence order.
+	strict          bool                      // Reject keys without a matching struct field.
+	envOverrides    bool                      // Override decoded values with environment variables.
+	envPrefix       string                    // The prefix of the override variables.
+	envSeparator    string                    // The separator of the override variable name parts.
+	envLookup       bool                      // Configure the env providers with the fields below.
+	envLayers       []provider.EnvLayer       // The variable layers of the env sources.
+	envVars         map[string]string         // The caller-supplied variables of the env sources.
+	envExport       bool                      // Export the .env variables to the process environment.
+	interpolate     bool                      // Resolve the references in string values.
+	secretResolvers map[string]SecretResolver // The secret resolvers by backend name.
+	encryptionKey   []byte                    // The key of the ENC[...] values.
+	schema          []byte                    // The JSON Schema of the raw documents.
+	flags           *Flags                    // The command-line flags overriding every source.
+	mu              sync.RWMutex
+	parsedConfig    interface{}
+	template        []byte        // The env template of the last load, reused on reload.
+	states          []fileState   // The state of the watched files at the last load.
+	onChange        []ChangeFunc  // Callbacks notified after a reload.
+	onError         []func(error) // Callbacks notified when a reload fails.
 }
 
-var c *Config
+// layer binds a source to the provider decoding it.
+type layer struct {
+	src Source
+	p   Provider
+}
 
-func WithFile(filename string) (*Config, error) {
+func WithFile(filename string, opts ...Option) (*Config, error) {
 	cfgType := DetectConfigType(filename)
-	return New(cfgType, filename)
+	return New(cfgType, filename, opts...)
 }
 
-func New(cfgType Type, filename string) (*Config, error) {
+func New(cfgType Type, filename string, opts ...Option) (*Config, error) {
 	if filename != "" {
-		if _, err := os.Stat(filename); err != nil {
-			return nil, fmt.Errorf("config %w", err)
+		if err := statFile(filename); err != nil {
+			return nil, err
 		}
 	}
-	c = &Config{
+	cfg := &Config{
 		cfgType:  cfgType,
 		filename: filename,
 	}
 
-	return c.initProviders()
+	return cfg.initProviders([]Source{{Type: cfgType, Filename: filename}}, opts)
+}
+
+// NewLayered creates a configuration merging the sources in order.
+// Every source is decoded on top of the previous ones, so the last one wins.
+// The first source determines the type used by Encode.
+func NewLayered(sources []Source, opts ...Option) (*Config, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("config: no sources")
+	}
+	for _, src := range sources {
+		if src.readFile() || src.Filename != "" {
+			if err := statFile(src.Filename); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cfg := &Config{
+		cfgType:  sources[0].Type,
+		filename: sources[0].Filename,
+	}
+
+	return cfg.initProviders(sources, opts)
+}
+
+// statFile checks that filename exists.
+func statFile(filename string) error {
+	_, err := os.Stat(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrFileNotFound{Filename: filename}
+	}
+	if err != nil {
+		return fmt.Errorf("config %w", err)
+	}
+
+	return nil
 }
 
 // initProviders initializes the configuration providers.
-func (c *Config) initProviders() (*Config, error) {
+func (c *Config) initProviders(sources []Source, opts []Option) (*Config, error) {
+	for _, opt := range opts {
+		opt(c)
+	}
+
 	c.providers = make(map[Type]Provider)
+	c.layers = make([]layer, 0, len(sources))
 
-	switch c.cfgType {
-	case JSONConfig:
-		c.providers[JSONConfig] = &provider.JSONProvider{}
-	case YamlConfig:
-		c.providers[YamlConfig] = &provider.YamlProvider{}
-	case TomlConfig:
-		c.providers[TomlConfig] = &provider.TomlProvider{}
-	case EnvConfig:
-		c.providers[EnvConfig] = &provider.EnvProvider{Filename: c.filename}
-	default:
-		return nil, ErrUnsupportedConfigType(c.cfgType)
+	for _, src := range sources {
+		p, err := newProvider(src)
+		if err != nil {
+			return nil, err
+		}
+		if ep, ok := p.(*provider.EnvProvider); ok && c.envLookup {
+			ep.Layers, ep.Vars, ep.Export = c.envLayers, c.envVars, c.envExport
+		}
+		if _, ok := c.providers[src.Type]; !ok {
+			c.providers[src.Type] = p
+		}
+		c.layers = append(c.layers, layer{src: src, p: p})
 	}
 
 	return c, nil
@@ -71,61 +163,172 @@ func (c *Config) getProvider() (Provider, error) {
 	return p, nil
 }
 
-// LoadConfig loads the configuration from the providers.
-func LoadConfig(conf interface{}, filename string, data []byte) error {
-	var err error
-	if c == nil || c.filename != filename {
-		c, err = WithFile(filename)
+// LoadConfig loads the configuration from the providers into conf.
+// The configuration is registered under DefaultName and reused while filename is unchanged
+// and no options are given; use Load to hold several independent configurations.
+func LoadConfig(conf interface{}, filename string, data []byte, opts ...Option) error {
+	cfg := Get(DefaultName)
+	if cfg == nil || cfg.filename != filename || len(opts) > 0 {
+		var err error
+		cfg, err = WithFile(filename, opts...)
 		if err != nil {
 			return err
 		}
+		Register(DefaultName, cfg)
+	}
+
+	return cfg.LoadConfig(conf, data)
+}
+
+// Load creates a new configuration for filename and loads it into conf.
+func Load(conf interface{}, filename string, data []byte, opts ...Option) (*Config, error) {
+	cfg, err := WithFile(filename, opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.LoadConfig(conf, data)
+	if err = cfg.LoadConfig(conf, data); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
 }
+
+// LoadConfig sets the defaults of conf, decodes every source into it in order and validates the result.
+// With WithSchema, the raw documents are checked against the JSON Schema before being decoded.
+// data is the YAML template of EnvConfig sources created without one.
 func (c *Config) LoadConfig(conf interface{}, data []byte) error {
-	if c.cfgType == EnvConfig {
-		if data == nil {
-			return fmt.Errorf("missing template data")
-		}
-	} else {
-		var err error
-		data, err = os.ReadFile(c.filename)
+	states := statFiles(c.watchedFiles())
+	if err := defaults.Set(conf); err != nil {
+		return err
+	}
+
+	contents := make([][]byte, len(c.layers))
+	for i, l := range c.layers {
+		content, err := l.read(data)
 		if err != nil {
 			return err
 		}
+		contents[i] = content
 	}
 
-	p, err := c.getProvider()
-	if err != nil {
-		return err
+	if c.schema != nil {
+		if err := c.validateSchema(contents, conf); err != nil {
+			return err
+		}
 	}
 
-	if err = defaults.Set(conf); err != nil {
-		return err
+	for i, l := range c.layers {
+		if err := c.decode(l, contents[i], conf); err != nil {
+			return fmt.Errorf("decode %w", decodeError(err, l.src.origin(), contents[i]))
+		}
 	}
 
-	if err = p.Decode(data, conf); err != nil {
-		return fmt.Errorf("decode %w", err)
+	if c.envOverrides {
+		if err := pkg.ApplyEnv(conf, c.envPrefix, c.envSeparator, os.LookupEnv); err != nil {
+			return fmt.Errorf("env override %w", err)
+		}
 	}
 
+	if c.flags != nil {
+		if err := c.flags.Apply(conf); err != nil {
+			return err
+		}
+	}
+
+	if err := c.decryptValues(conf); err != nil {
+		return fmt.Errorf("decrypt %w", err)
+	}
+
+	if c.interpolate {
+		if err := pkg.Interpolate(conf, os.LookupEnv); err != nil {
+			return fmt.Errorf("interpolate %w", err)
+		}
+	}
+
+	if err := c.resolveSecrets(conf); err != nil {
+		return fmt.Errorf("resolve %w", err)
+	}
+
+	if err := Validate(conf); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
 	c.parsedConfig = conf
+	c.template = data
+	c.states = states
+	c.mu.Unlock()
 
 	return nil
 }
 
-// GetConfig returns the configuration.
+// decode decodes the content of the layer into conf.
+func (c *Config) decode(l layer, content []byte, conf interface{}) error {
+	if !c.strict {
+		return l.p.Decode(content, conf)
+	}
+
+	sp, ok := l.p.(StrictProvider)
+	if !ok {
+		return fmt.Errorf("strict decoding not supported by %q provider", l.src.Type)
+	}
+
+	err := sp.DecodeStrict(content, conf)
+	var uErr *UnknownFieldsError
+	if errors.As(err, &uErr) {
+		uErr.Filename = l.src.Filename
+	}
+
+	return err
+}
+
+// read returns the content of the layer, falling back to template for env layers.
+func (l layer) read(template []byte) ([]byte, error) {
+	switch {
+	case l.src.Data != nil:
+		return l.src.Data, nil
+	case l.src.Type == EnvConfig:
+		if template == nil {
+			return nil, ErrMissingTemplate
+		}
+		return template, nil
+	default:
+		data, err := os.ReadFile(l.src.Filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrFileNotFound{Filename: l.src.Filename}
+		}
+		return data, err
+	}
+}
+
+// GetConfig returns the configuration used by LoadConfig.
 func GetConfig() *Config {
-	return c
+	return Get(DefaultName)
+}
+
+// Current returns the last successfully loaded configuration.
+func (c *Config) Current() interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.parsedConfig
+}
+
+// Redacted returns a copy of the current configuration whose sensitive fields, tagged
+// sensitive:"true" or redact:"true", are masked. It is safe to log.
+func (c *Config) Redacted() interface{} {
+	return pkg.Redact(c.Current())
 }
 
+// Encode encodes the current configuration with the provider of its type, sensitive fields masked.
 func (c *Config) Encode() ([]byte, error) {
 	p, err := c.getProvider()
 	if err != nil {
 		return nil, err
 	}
 
-	return p.Encode(c.parsedConfig)
+	return p.Encode(c.Current())
 }
 
 func (c *Config) String() string {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/rottendev/config/provider"
 	"github.com/stretchr/testify/require"
 )
 
@@ -129,10 +130,20 @@ func TestConfig_ENV(t *testing.T) {
 	data, err := os.ReadFile("testdata/config.test.env.yaml")
 	require.NoError(t, err)
 
-	t.Run("default env", func(t *testing.T) {
+	t.Run("unset env", func(t *testing.T) {
 		defer resetEnv()
 		cfg, _ := New(EnvConfig, "")
 		err = cfg.LoadConfig(&setting, data)
+		var uErr *UnsetVariablesError
+		require.ErrorAs(t, err, &uErr)
+		require.Len(t, uErr.Variables, 5)
+		require.Equal(t, "decode unset variables: APP_NAME (line 2), APP_PORT (line 3), FILES_DIR (line 4), MODULES (line 5), REGION (line 6)", err.Error())
+	})
+
+	t.Run("default env", func(t *testing.T) {
+		defer resetEnv()
+		cfg, _ := New(EnvConfig, "")
+		err = cfg.LoadConfig(&setting, []byte("app:\n    name: ${APP_NAME:-}\n    port: ${APP_PORT:-}\nfiles_dir: ${FILES_DIR:-}\nmodules: ${MODULES:-}\nregion: ${REGION:-}\n"))
 		require.NoError(t, err)
 		require.Equal(t, "app", setting.App.Name)
 		require.Equal(t, 8080, setting.App.Port)
@@ -176,6 +187,9 @@ func TestConfig_ENV(t *testing.T) {
 		require.Len(t, setting.Modules, 2)
 		require.Equal(t, "module6", setting.Modules[0])
 		require.Equal(t, "module7", setting.Modules[1])
+
+		_, ok := os.LookupEnv("APP_NAME")
+		require.False(t, ok, ".env values must not leak into the process environment")
 	})
 
 	t.Run("Machine and file env conflicts", func(t *testing.T) {
@@ -221,6 +235,85 @@ func TestConfig_ENV(t *testing.T) {
 	})
 }
 
+func TestConfig_EnvOnly(t *testing.T) {
+	defer resetEnv()
+	_ = os.Setenv("APP_NAME", "App Env from machine")
+
+	setting := testConfig{}
+	cfg, err := NewLayered([]Source{EnvOnlySource("testdata/config.test.env")})
+	require.NoError(t, err)
+	require.NoError(t, cfg.LoadConfig(&setting, nil))
+	require.Equal(t, "App Env from machine", setting.App.Name)
+	require.Equal(t, 8085, setting.App.Port)
+	require.Equal(t, "us-west-4", setting.Region)
+	require.Equal(t, "appEnv", *setting.FilesDir)
+	require.Equal(t, []string{"module6", "module7"}, setting.Modules)
+
+	_ = os.Setenv("APP_PORT", "invalid")
+	err = cfg.LoadConfig(&setting, nil)
+	require.ErrorContains(t, err, "APP_PORT:")
+}
+
+func TestConfig_Interpolation(t *testing.T) {
+	t.Setenv("MODULE", "env-module")
+	data := []byte("app:\n    name: ${region}-app\nregion: us-west-8\nfiles_dir: ${app.name}/files\nmodules:\n    - ${env:MODULE}\n    - $${literal}\n")
+
+	setting := testConfig{}
+	cfg, err := NewLayered([]Source{BytesSource(YamlConfig, data)}, WithInterpolation(true))
+	require.NoError(t, err)
+	require.NoError(t, cfg.LoadConfig(&setting, nil))
+	require.Equal(t, "us-west-8-app", setting.App.Name)
+	require.Equal(t, "us-west-8-app/files", *setting.FilesDir)
+	require.Equal(t, []string{"env-module", "${literal}"}, setting.Modules)
+
+	cfg, err = NewLayered([]Source{BytesSource(YamlConfig, []byte("region: ${files_dir}\nfiles_dir: ${region}\n"))}, WithInterpolation(true))
+	require.NoError(t, err)
+	err = cfg.LoadConfig(&testConfig{}, nil)
+	var iErr *InterpolationError
+	require.ErrorAs(t, err, &iErr)
+	require.Equal(t, "files_dir", iErr.Path)
+	require.EqualError(t, err, "interpolate files_dir: reference cycle region -> files_dir -> region")
+
+	setting = testConfig{}
+	cfg, err = NewLayered([]Source{BytesSource(YamlConfig, data)})
+	require.NoError(t, err)
+	require.NoError(t, cfg.LoadConfig(&setting, nil))
+	require.Equal(t, "${region}-app", setting.App.Name)
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	type redactedConfig struct {
+		App struct {
+			Name string `yaml:"name" json:"name"`
+		} `yaml:"app" json:"app"`
+		Password string `yaml:"password" json:"password" sensitive:"true"`
+	}
+
+	for _, cfgType := range []Type{YamlConfig, JSONConfig, TomlConfig, HCLConfig, INIConfig, PropertiesConfig, EnvConfig} {
+		t.Run(string(cfgType), func(t *testing.T) {
+			setting := redactedConfig{Password: "s3cr3t"}
+			setting.App.Name = "app"
+
+			cfg, err := New(cfgType, "")
+			require.NoError(t, err)
+			cfg.parsedConfig = &setting
+
+			data, err := cfg.Encode()
+			require.NoError(t, err)
+			require.NotContains(t, string(data), "s3cr3t")
+			require.Contains(t, string(data), "******")
+			require.Contains(t, string(data), "app")
+		})
+	}
+
+	setting := redactedConfig{Password: "s3cr3t"}
+	cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("password: s3cr3t\n"))})
+	require.NoError(t, err)
+	require.NoError(t, cfg.LoadConfig(&setting, nil))
+	require.Equal(t, "******", cfg.Redacted().(*redactedConfig).Password)
+	require.Equal(t, "s3cr3t", setting.Password)
+}
+
 func TestErrUnsupportedConfigType_Error(t *testing.T) {
 	unErr := ErrUnsupportedConfigType("test")
 	require.Equal(t, "unsupported config type: \"test\"", unErr.Error())
@@ -233,3 +326,213 @@ func TestErrUnsupportedConfigType_Error(t *testing.T) {
 	_, err = New("txt", "testdata/config.test.txt")
 	require.Error(t, err)
 }
+
+func TestNewLayered(t *testing.T) {
+	type layeredConfig struct {
+		App struct {
+			Name string `yaml:"name" json:"name" default:"app"`
+			Port int    `yaml:"port" json:"port" default:"8080"`
+		} `json:"app"`
+		Region  string            `yaml:"region" json:"region" default:"us-west-1"`
+		Labels  map[string]string `yaml:"labels" json:"labels"`
+		Modules []string          `yaml:"modules" json:"modulesAA"`
+	}
+
+	t.Run("last wins", func(t *testing.T) {
+		defer resetEnv()
+		_ = os.Setenv("REGION", "eu-central-1")
+
+		cfg, err := NewLayered([]Source{
+			FileSource("testdata/layered/base.yaml"),
+			FileSource("testdata/layered/override.json"),
+			BytesSource(TomlConfig, []byte("Region = \"us-east-1\"\n")),
+			EnvSource("", []byte("region: ${REGION}\n")),
+		})
+		require.NoError(t, err)
+
+		setting := layeredConfig{}
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, "override", setting.App.Name)
+		require.Equal(t, 8081, setting.App.Port)
+		require.Equal(t, "eu-central-1", setting.Region)
+		require.Equal(t, map[string]string{"team": "core", "tier": "frontend"}, setting.Labels)
+		require.Equal(t, []string{"module2", "module3"}, setting.Modules)
+		require.Equal(t, YamlConfig, cfg.cfgType)
+	})
+
+	t.Run("defaults only", func(t *testing.T) {
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("{}"))})
+		require.NoError(t, err)
+
+		setting := layeredConfig{}
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, "app", setting.App.Name)
+		require.Equal(t, 8080, setting.App.Port)
+		require.Equal(t, "us-west-1", setting.Region)
+	})
+
+	t.Run("env template from data", func(t *testing.T) {
+		cfg, err := NewLayered([]Source{
+			FileSource("testdata/layered/base.yaml"),
+			EnvSource("testdata/config.test.env", nil),
+		})
+		require.NoError(t, err)
+
+		setting := layeredConfig{}
+		require.EqualError(t, cfg.LoadConfig(&setting, nil), "missing template data")
+		require.NoError(t, cfg.LoadConfig(&setting, []byte("app:\n    port: ${APP_PORT}\n")))
+		require.Equal(t, "base", setting.App.Name)
+		require.Equal(t, 8085, setting.App.Port)
+		resetEnv()
+	})
+
+	t.Run("env lookup", func(t *testing.T) {
+		t.Setenv("REGION", "process")
+		template := []byte("app:\n    name: ${APP_NAME}\n    port: ${APP_PORT}\nregion: ${REGION}\n")
+		vars := map[string]string{"APP_NAME": "vars", "REGION": "vars"}
+
+		cfg, err := NewLayered([]Source{EnvSource("testdata/config.test.env", template)},
+			WithEnvLookup([]provider.EnvLayer{provider.VarsLayer, provider.ProcessEnvLayer, provider.EnvFileLayer}, vars, false))
+		require.NoError(t, err)
+		setting := layeredConfig{}
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, "appEnv", setting.App.Name)
+		require.Equal(t, 8085, setting.App.Port)
+		require.Equal(t, "us-west-4", setting.Region)
+
+		// The option only applies to its config.
+		cfg, err = NewLayered([]Source{EnvSource("testdata/config.test.env", template)})
+		require.NoError(t, err)
+		setting = layeredConfig{}
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, "process", setting.Region)
+
+		// t.Setenv restores the variables exported from the .env file.
+		for _, name := range []string{"APP_NAME", "APP_PORT", "FILES_DIR", "MODULES"} {
+			t.Setenv(name, "")
+			require.NoError(t, os.Unsetenv(name))
+		}
+		cfg, err = NewLayered([]Source{EnvSource("testdata/config.test.env", template)}, WithEnvLookup(nil, nil, true))
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&layeredConfig{}, nil))
+		require.Equal(t, "8085", os.Getenv("APP_PORT"))
+		require.Equal(t, "process", os.Getenv("REGION"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewLayered(nil)
+		require.Error(t, err)
+
+		_, err = NewLayered([]Source{FileSource("testdata/layered/missing.yaml")})
+		require.Error(t, err)
+
+		_, err = NewLayered([]Source{BytesSource("txt", []byte("a"))})
+		require.ErrorAs(t, err, new(ErrUnsupportedConfigType))
+
+		cfg, err := NewLayered([]Source{
+			FileSource("testdata/layered/base.yaml"),
+			BytesSource(JSONConfig, []byte("{")),
+		})
+		require.NoError(t, err)
+		require.ErrorContains(t, cfg.LoadConfig(&layeredConfig{}, nil), "decode line 1, column 2: unexpected end of JSON input")
+	})
+}
+
+func TestConfig_Strict(t *testing.T) {
+	setting := testConfig{}
+	require.NoError(t, LoadConfig(&setting, "testdata/config.test.json", nil))
+
+	err := LoadConfig(&setting, "testdata/config.test.json", nil, WithStrict(true))
+	var uErr *UnknownFieldsError
+	require.ErrorAs(t, err, &uErr)
+	require.Equal(t, "testdata/config.test.json", uErr.Filename)
+	require.Equal(t, []UnknownField{{Path: "Version", Line: 1, Column: 110}}, uErr.Fields)
+	require.EqualError(t, err, "decode testdata/config.test.json: unknown fields: Version (line 1, column 110)")
+
+	for _, filename := range []string{"testdata/config.test.yaml", "testdata/config.test.toml"} {
+		cfg, err := WithFile(filename, WithStrict(true))
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&testConfig{}, nil))
+	}
+
+	cfg, err := NewLayered([]Source{
+		FileSource("testdata/config.test.yaml"),
+		BytesSource(YamlConfig, []byte("app:\n    prot: 8080\n")),
+	}, WithStrict(true))
+	require.NoError(t, err)
+	require.EqualError(t, cfg.LoadConfig(&testConfig{}, nil), "decode unknown fields: app.prot (line 2, column 5)")
+}
+
+func TestConfig_EnvOverrides(t *testing.T) {
+	t.Setenv("APP_APP_PORT", "9090")
+	t.Setenv("APP_MODULES", "[\"a\",\"b\"]")
+	t.Setenv("APP_FILES_DIR", "/srv")
+	t.Setenv("REGION", "ignored")
+
+	for _, filename := range []string{"testdata/config.test.yaml", "testdata/config.test.json", "testdata/config.test.toml"} {
+		t.Run(filename, func(t *testing.T) {
+			setting := testConfig{}
+			_, err := Load(&setting, filename, nil, WithEnvOverrides("APP", ""))
+			require.NoError(t, err)
+			require.Equal(t, 9090, setting.App.Port)
+			require.Equal(t, []string{"a", "b"}, setting.Modules)
+			require.Equal(t, "/srv", *setting.FilesDir)
+			require.NotEqual(t, "ignored", setting.Region)
+		})
+	}
+
+	t.Run("separator", func(t *testing.T) {
+		t.Setenv("SVC__APP__PORT", "invalid")
+
+		_, err := Load(&testConfig{}, "testdata/config.test.yaml", nil, WithEnvOverrides("SVC", "__"))
+		require.ErrorContains(t, err, "env override SVC__APP__PORT:")
+	})
+}
+
+func TestConfig_HCL(t *testing.T) {
+	setting := testConfig{}
+	cfg, err := Load(&setting, "testdata/config.test.hcl", nil)
+	require.NoError(t, err)
+	require.Equal(t, HCLConfig, cfg.cfgType)
+	require.Equal(t, "appHcl", setting.App.Name)
+	require.Equal(t, 8086, setting.App.Port)
+	require.Equal(t, "us-west-5", setting.Region)
+	require.Equal(t, "appHcl", *setting.FilesDir)
+	require.Equal(t, []string{"module8", "module9"}, setting.Modules)
+
+	data, err := cfg.Encode()
+	require.NoError(t, err)
+	require.Equal(t, "App {\n  Name = \"appHcl\"\n  Port = 8086\n}\n\nRegion = \"us-west-5\"\n\nFilesDir = \"appHcl\"\n\nModules = [\"module8\", \"module9\"]\n", string(data))
+}
+
+func TestConfig_INI(t *testing.T) {
+	setting := testConfig{}
+	cfg, err := Load(&setting, "testdata/config.test.ini", nil)
+	require.NoError(t, err)
+	require.Equal(t, INIConfig, cfg.cfgType)
+	require.Equal(t, "appIni", setting.App.Name)
+	require.Equal(t, 8087, setting.App.Port)
+	require.Equal(t, "us-west-6", setting.Region)
+	require.Equal(t, "appIni", *setting.FilesDir)
+	require.Equal(t, []string{"module10", "module11"}, setting.Modules)
+
+	data, err := cfg.Encode()
+	require.NoError(t, err)
+	require.Equal(t, "region = us-west-6\nfiles_dir = appIni\nmodules = [module10, module11]\n\n[app]\nname = appIni\nport = 8087\n", string(data))
+}
+
+func TestConfig_Properties(t *testing.T) {
+	setting := testConfig{}
+	cfg, err := Load(&setting, "testdata/config.test.properties", nil)
+	require.NoError(t, err)
+	require.Equal(t, PropertiesConfig, cfg.cfgType)
+	require.Equal(t, "appProperties", setting.App.Name)
+	require.Equal(t, 8088, setting.App.Port)
+	require.Equal(t, "us-west-7", setting.Region)
+	require.Equal(t, "appProperties", *setting.FilesDir)
+	require.Equal(t, []string{"module12", "module13"}, setting.Modules)
+
+	data, err := cfg.Encode()
+	require.NoError(t, err)
+	require.Equal(t, "app.name=appProperties\napp.port=8088\nregion=us-west-7\nfiles_dir=appProperties\nmodules=[module12, module13]\n", string(data))
+}
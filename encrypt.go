@@ -0,0 +1,377 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/printer"
+	"github.com/hashicorp/hcl/hcl/token"
+	"github.com/rottendev/config/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultKeyEnv is the environment variable holding the base64 decryption key
+// used when no key is given with WithEncryptionKey.
+const DefaultKeyEnv = "CONFIG_ENCRYPTION_KEY"
+
+const (
+	encPrefix = "ENC[AES256_GCM,data:"
+	encSuffix = "]"
+)
+
+// ErrNoEncryptionKey is returned when encrypted values are loaded without a key.
+var ErrNoEncryptionKey = errors.New("no encryption key: use WithEncryptionKey or set " + DefaultKeyEnv)
+
+// DecryptionError is returned when an encrypted value cannot be decrypted.
+type DecryptionError struct {
+	Path string // The dotted key path of the value.
+	Err  error
+}
+
+func (e *DecryptionError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *DecryptionError) Unwrap() error {
+	return e.Err
+}
+
+// GenerateKey returns a random AES-256 key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// KeyFromEnv decodes the base64 key held by the environment variable name.
+func KeyFromEnv(name string) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("encryption key: %s is not set", name)
+	}
+
+	return decodeKey(value)
+}
+
+// KeyFromFile decodes the base64 key stored in filename.
+func KeyFromFile(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key: %w", err)
+	}
+
+	return decodeKey(string(data))
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key: got %d bytes, want 32", len(key))
+	}
+
+	return key, nil
+}
+
+// IsEncrypted reports whether s is an ENC[...] envelope.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, encPrefix) && strings.HasSuffix(s, encSuffix)
+}
+
+// EncryptValue encrypts plaintext with AES-256-GCM into an ENC[AES256_GCM,data:...] envelope.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed) + encSuffix, nil
+}
+
+// DecryptValue decrypts an envelope created by EncryptValue.
+func DecryptValue(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("not an ENC[AES256_GCM,...] value")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, encPrefix), encSuffix))
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt value: wrong key or corrupted data")
+	}
+
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// decryptValues replaces the encrypted strings of conf by their plaintext, including those
+// held by slices, maps and interfaces. The key is only looked up when an encrypted value is found.
+func (c *Config) decryptValues(conf interface{}) error {
+	key := c.encryptionKey
+	decrypt := func(path, value string) (string, error) {
+		if key == nil {
+			var err error
+			if _, ok := os.LookupEnv(DefaultKeyEnv); !ok {
+				return "", &DecryptionError{Path: path, Err: ErrNoEncryptionKey}
+			}
+			if key, err = KeyFromEnv(DefaultKeyEnv); err != nil {
+				return "", &DecryptionError{Path: path, Err: err}
+			}
+		}
+
+		plaintext, err := DecryptValue(key, value)
+		if err != nil {
+			return "", &DecryptionError{Path: path, Err: err}
+		}
+		return plaintext, nil
+	}
+
+	return pkg.Walk(conf, func(f pkg.Field) error {
+		v := reflect.Indirect(f.Value)
+		if v.Kind() != reflect.String || !IsEncrypted(v.String()) {
+			return nil
+		}
+		plaintext, err := decrypt(f.Path, v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(plaintext)
+
+		return nil
+	}, pkg.WalkElements())
+}
+
+// EncryptFile encrypts the string values at the dotted key paths of filename in place,
+// e.g. "db.password". Values already encrypted are left unchanged.
+// YAML and HCL files keep their comments and key order; other formats are re-encoded by
+// their provider, which must support encoding maps.
+func EncryptFile(filename string, key []byte, paths ...string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	switch cfgType := DetectConfigType(filename); cfgType {
+	case YamlConfig:
+		data, err = encryptYaml(data, key, paths)
+	case HCLConfig:
+		data, err = encryptHCL(data, key, paths)
+	default:
+		data, err = encryptDocument(cfgType, data, key, paths)
+	}
+	if err != nil {
+		return fmt.Errorf("encrypt %s: %w", filename, err)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, data, info.Mode().Perm())
+}
+
+func encryptYaml(data []byte, key []byte, paths []string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, errors.New("empty document")
+	}
+
+	for _, path := range paths {
+		node := doc.Content[0]
+		for _, part := range strings.Split(path, ".") {
+			node = yamlChild(node, part)
+			if node == nil {
+				return nil, fmt.Errorf("key %q not found", path)
+			}
+		}
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+			return nil, fmt.Errorf("key %q is not a string", path)
+		}
+		if IsEncrypted(node.Value) {
+			continue
+		}
+
+		value, err := EncryptValue(key, node.Value)
+		if err != nil {
+			return nil, err
+		}
+		node.Value, node.Style = value, 0
+	}
+
+	b := bytes.Buffer{}
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func yamlChild(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func encryptHCL(data []byte, key []byte, paths []string) ([]byte, error) {
+	file, err := hcl.ParseBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := file.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, errors.New("expected an object list")
+	}
+
+	for _, path := range paths {
+		item := hclItem(list, strings.Split(path, "."))
+		if item == nil {
+			return nil, fmt.Errorf("key %q not found", path)
+		}
+		lit, ok := item.Val.(*ast.LiteralType)
+		if !ok || lit.Token.Type != token.STRING {
+			return nil, fmt.Errorf("key %q is not a string", path)
+		}
+		value := lit.Token.Value().(string)
+		if IsEncrypted(value) {
+			continue
+		}
+
+		if value, err = EncryptValue(key, value); err != nil {
+			return nil, err
+		}
+		lit.Token.Text, lit.Token.JSON = strconv.Quote(value), false
+	}
+
+	b := bytes.Buffer{}
+	if err = printer.Fprint(&b, file); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+// hclItem returns the item of list at the key path parts, following blocks, their labels
+// and object values, e.g. db.password for the password attribute of a db block.
+func hclItem(list *ast.ObjectList, parts []string) *ast.ObjectItem {
+	for _, item := range list.Items {
+		if len(item.Keys) > len(parts) {
+			continue
+		}
+		matched := true
+		for i, k := range item.Keys {
+			if name, _ := k.Token.Value().(string); name != parts[i] {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		rest := parts[len(item.Keys):]
+		if len(rest) == 0 {
+			return item
+		}
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			if found := hclItem(ot.List, rest); found != nil {
+				return found
+			}
+		}
+	}
+
+	return nil
+}
+
+func encryptDocument(cfgType Type, data []byte, key []byte, paths []string) ([]byte, error) {
+	p, err := newProvider(Source{Type: cfgType})
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]interface{})
+	if err = p.Decode(data, &doc); err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		parts := strings.Split(path, ".")
+		m := doc
+		for _, part := range parts[:len(parts)-1] {
+			if m, _ = m[part].(map[string]interface{}); m == nil {
+				return nil, fmt.Errorf("key %q not found", path)
+			}
+		}
+
+		last := parts[len(parts)-1]
+		value, ok := m[last]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", path)
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("key %q is not a string", path)
+		}
+		if IsEncrypted(s) {
+			continue
+		}
+		if m[last], err = EncryptValue(key, s); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.Encode(doc)
+}
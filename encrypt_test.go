@@ -0,0 +1,231 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptValue(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	value, err := EncryptValue(key, "s3cr3t")
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(value))
+	require.NotContains(t, value, "s3cr3t")
+
+	plaintext, err := DecryptValue(key, value)
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", plaintext)
+
+	other, err := GenerateKey()
+	require.NoError(t, err)
+	_, err = DecryptValue(other, value)
+	require.EqualError(t, err, "cannot decrypt value: wrong key or corrupted data")
+
+	_, err = DecryptValue(key, "s3cr3t")
+	require.Error(t, err)
+	_, err = EncryptValue([]byte("short"), "s3cr3t")
+	require.Error(t, err)
+}
+
+func TestKeyFrom(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	encoded := base64.StdEncoding.EncodeToString(key)
+
+	t.Setenv("TEST_CONFIG_KEY", encoded)
+	got, err := KeyFromEnv("TEST_CONFIG_KEY")
+	require.NoError(t, err)
+	require.Equal(t, key, got)
+
+	filename := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(filename, []byte(encoded+"\n"), 0o600))
+	got, err = KeyFromFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, key, got)
+
+	_, err = KeyFromEnv("TEST_CONFIG_KEY_MISSING")
+	require.EqualError(t, err, "encryption key: TEST_CONFIG_KEY_MISSING is not set")
+	t.Setenv("TEST_CONFIG_KEY", base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = KeyFromEnv("TEST_CONFIG_KEY")
+	require.EqualError(t, err, "encryption key: got 5 bytes, want 32")
+}
+
+func TestConfig_Encrypted(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	password, err := EncryptValue(key, "s3cr3t")
+	require.NoError(t, err)
+
+	type encryptedConfig struct {
+		DB struct {
+			User     string `yaml:"user" json:"user" toml:"user"`
+			Password string `yaml:"password" json:"password" toml:"password"`
+		} `yaml:"db" json:"db" toml:"db"`
+	}
+
+	sources := map[Type][]byte{
+		YamlConfig: []byte("db:\n  user: admin\n  password: " + password + "\n"),
+		JSONConfig: []byte(`{"db":{"user":"admin","password":"` + password + `"}}`),
+		TomlConfig: []byte("[db]\nuser = \"admin\"\npassword = \"" + password + "\"\n"),
+	}
+	for cfgType, data := range sources {
+		t.Run(string(cfgType), func(t *testing.T) {
+			setting := encryptedConfig{}
+			cfg, err := NewLayered([]Source{BytesSource(cfgType, data)}, WithEncryptionKey(key))
+			require.NoError(t, err)
+			require.NoError(t, cfg.LoadConfig(&setting, nil))
+			require.Equal(t, "admin", setting.DB.User)
+			require.Equal(t, "s3cr3t", setting.DB.Password)
+		})
+	}
+
+	t.Run("Key from env", func(t *testing.T) {
+		t.Setenv(DefaultKeyEnv, base64.StdEncoding.EncodeToString(key))
+
+		setting := encryptedConfig{}
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, sources[YamlConfig])})
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, "s3cr3t", setting.DB.Password)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, sources[YamlConfig])})
+		require.NoError(t, err)
+		err = cfg.LoadConfig(&encryptedConfig{}, nil)
+		require.ErrorIs(t, err, ErrNoEncryptionKey)
+		var dErr *DecryptionError
+		require.ErrorAs(t, err, &dErr)
+		require.Equal(t, "db.password", dErr.Path)
+
+		other, err := GenerateKey()
+		require.NoError(t, err)
+		cfg, err = NewLayered([]Source{BytesSource(YamlConfig, sources[YamlConfig])}, WithEncryptionKey(other))
+		require.NoError(t, err)
+		err = cfg.LoadConfig(&encryptedConfig{}, nil)
+		require.EqualError(t, err, "decrypt db.password: cannot decrypt value: wrong key or corrupted data")
+	})
+
+	t.Run("Elements", func(t *testing.T) {
+		type server struct {
+			Host     string `yaml:"host"`
+			Password string `yaml:"password"`
+		}
+		setting := struct {
+			Servers []server               `yaml:"servers"`
+			ByName  map[string]server      `yaml:"by_name"`
+			Backups map[string]*server     `yaml:"backups"`
+			Extra   map[string]interface{} `yaml:"extra"`
+		}{}
+		data := []byte("servers:\n  - host: a\n  - host: b\n    password: " + password + "\n" +
+			"by_name:\n  a:\n    password: " + password + "\nbackups:\n  c:\n    password: " + password + "\n" +
+			"extra:\n  pw: " + password + "\n  list:\n    - " + password + "\n")
+
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, data)}, WithEncryptionKey(key))
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, []server{{Host: "a"}, {Host: "b", Password: "s3cr3t"}}, setting.Servers)
+		require.Equal(t, map[string]server{"a": {Password: "s3cr3t"}}, setting.ByName)
+		require.Equal(t, "s3cr3t", setting.Backups["c"].Password)
+		require.Equal(t, map[string]interface{}{"pw": "s3cr3t", "list": []interface{}{"s3cr3t"}}, setting.Extra)
+
+		cfg, err = NewLayered([]Source{BytesSource(YamlConfig, data)})
+		require.NoError(t, err)
+		err = cfg.LoadConfig(&setting, nil)
+		var dErr *DecryptionError
+		require.ErrorAs(t, err, &dErr)
+		require.Equal(t, "servers[1].password", dErr.Path)
+	})
+}
+
+func TestEncryptFile(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	t.Run("Yaml", func(t *testing.T) {
+		filename := filepath.Join(dir, "config.yaml")
+		content := "# database\ndb:\n  user: admin\n  password: s3cr3t # rotate monthly\n  port: 5432\n"
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+		require.NoError(t, EncryptFile(filename, key, "db.password"))
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "s3cr3t")
+		require.Contains(t, string(data), "# database\ndb:\n  user: admin\n  password: ENC[AES256_GCM,data:")
+		require.Contains(t, string(data), "# rotate monthly\n  port: 5432\n")
+
+		// Encrypting again leaves the value unchanged.
+		require.NoError(t, EncryptFile(filename, key, "db.password"))
+		again, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		require.Equal(t, string(data), string(again))
+
+		setting := struct {
+			DB struct {
+				Password string `yaml:"password"`
+			} `yaml:"db"`
+		}{}
+		_, err = Load(&setting, filename, nil, WithEncryptionKey(key))
+		require.NoError(t, err)
+		require.Equal(t, "s3cr3t", setting.DB.Password)
+
+		require.ErrorContains(t, EncryptFile(filename, key, "db.port"), `key "db.port" is not a string`)
+		require.ErrorContains(t, EncryptFile(filename, key, "db.missing"), `key "db.missing" not found`)
+	})
+
+	t.Run("Toml", func(t *testing.T) {
+		filename := filepath.Join(dir, "config.toml")
+		require.NoError(t, os.WriteFile(filename, []byte("[db]\nuser = \"admin\"\npassword = \"s3cr3t\"\n"), 0o600))
+
+		require.NoError(t, EncryptFile(filename, key, "db.password"))
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "s3cr3t")
+		require.Contains(t, string(data), `password = "ENC[AES256_GCM,data:`)
+
+		setting := struct {
+			DB struct {
+				User     string `toml:"user"`
+				Password string `toml:"password"`
+			} `toml:"db"`
+		}{}
+		_, err = Load(&setting, filename, nil, WithEncryptionKey(key))
+		require.NoError(t, err)
+		require.Equal(t, "admin", setting.DB.User)
+		require.Equal(t, "s3cr3t", setting.DB.Password)
+	})
+
+	t.Run("Hcl", func(t *testing.T) {
+		filename := filepath.Join(dir, "config.hcl")
+		content := "# database\ndb {\n  user     = \"admin\"\n  password = \"s3cr3t\" # rotate monthly\n  port     = 5432\n}\n"
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+		require.NoError(t, EncryptFile(filename, key, "db.password"))
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "s3cr3t")
+		require.Contains(t, string(data), "# database\ndb {\n  user     = \"admin\"\n  password = \"ENC[AES256_GCM,data:")
+		require.Contains(t, string(data), "# rotate monthly\n  port     = 5432\n}\n")
+
+		setting := struct {
+			DB struct {
+				User     string `hcl:"user"`
+				Password string `hcl:"password"`
+			} `hcl:"db"`
+		}{}
+		_, err = Load(&setting, filename, nil, WithEncryptionKey(key))
+		require.NoError(t, err)
+		require.Equal(t, "admin", setting.DB.User)
+		require.Equal(t, "s3cr3t", setting.DB.Password)
+
+		require.ErrorContains(t, EncryptFile(filename, key, "db.port"), `key "db.port" is not a string`)
+		require.ErrorContains(t, EncryptFile(filename, key, "db.missing"), `key "db.missing" not found`)
+	})
+}
@@ -1,7 +1,17 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/rottendev/config/pkg"
+	"gopkg.in/yaml.v3"
 )
 
 type ErrUnsupportedConfigType string
@@ -9,3 +19,124 @@ type ErrUnsupportedConfigType string
 func (e ErrUnsupportedConfigType) Error() string {
 	return fmt.Sprintf("unsupported config type: %q", string(e))
 }
+
+// ErrMissingTemplate is returned when an EnvConfig source is loaded without a template.
+var ErrMissingTemplate = errors.New("missing template data")
+
+// ErrFileNotFound is returned when a configuration file does not exist.
+// It matches fs.ErrNotExist with errors.Is.
+type ErrFileNotFound struct {
+	Filename string
+}
+
+func (e ErrFileNotFound) Error() string {
+	return fmt.Sprintf("config file not found: %q", e.Filename)
+}
+
+func (e ErrFileNotFound) Unwrap() error {
+	return fs.ErrNotExist
+}
+
+// SyntaxError is returned when a configuration document cannot be parsed.
+type SyntaxError struct {
+	Filename string // The decoded file, empty for raw data and templates.
+	Line     int    // The line of the error, 0 when unknown.
+	Column   int    // The column of the error, 0 when unknown.
+	Err      error  // The error of the underlying parser.
+}
+
+func (e *SyntaxError) Error() string {
+	return location(e.Filename, e.Line, e.Column, e.Err.Error()) + e.Err.Error()
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// TypeMismatchError is returned when a configuration value does not fit the type of its field.
+type TypeMismatchError struct {
+	Filename string // The decoded file, empty for raw data and templates.
+	Line     int    // The line of the value, 0 when unknown.
+	Column   int    // The column of the value, 0 when unknown.
+	Field    string // The field path, empty when unknown.
+	Err      error  // The error of the underlying decoder.
+}
+
+func (e *TypeMismatchError) Error() string {
+	return location(e.Filename, e.Line, e.Column, e.Err.Error()) + e.Err.Error()
+}
+
+func (e *TypeMismatchError) Unwrap() error {
+	return e.Err
+}
+
+// location formats the filename and the position of an error,
+// unless the decoder message already contains the line.
+func location(filename string, line, column int, msg string) string {
+	var parts []string
+	if filename != "" {
+		parts = append(parts, filename)
+	}
+	if line > 0 && !strings.Contains(msg, "line ") {
+		parts = append(parts, fmt.Sprintf("line %d, column %d", line, column))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return strings.Join(parts, ": ") + ": "
+}
+
+var (
+	yamlLineRegexp = regexp.MustCompile(`line (\d+):`)
+	tomlTypeRegexp = regexp.MustCompile(`^toml: (?:line (\d+) )?\(last key "([^"]*)"\): `)
+)
+
+// decodeError converts the error of a provider into a typed error carrying its position.
+func decodeError(err error, filename string, data []byte) error {
+	var (
+		jsonSyntax *json.SyntaxError
+		jsonType   *json.UnmarshalTypeError
+		yamlType   *yaml.TypeError
+		tomlParse  toml.ParseError
+	)
+
+	switch {
+	case errors.As(err, &jsonSyntax):
+		line, column := pkg.Position(data, int(jsonSyntax.Offset))
+		return &SyntaxError{Filename: filename, Line: line, Column: column, Err: err}
+	case errors.As(err, &jsonType):
+		line, column := pkg.Position(data, int(jsonType.Offset))
+		return &TypeMismatchError{Filename: filename, Line: line, Column: column, Field: jsonType.Field, Err: err}
+	case errors.As(err, &yamlType):
+		tErr := &TypeMismatchError{Filename: filename, Err: err}
+		if len(yamlType.Errors) > 0 {
+			tErr.Line = matchLine(yamlLineRegexp, yamlType.Errors[0])
+		}
+		return tErr
+	case errors.As(err, &tomlParse):
+		line, column := tomlParse.Position.Line, 0
+		if tomlParse.Position.Start > 0 {
+			line, column = pkg.Position(data, tomlParse.Position.Start)
+		}
+		return &SyntaxError{Filename: filename, Line: line, Column: column, Err: err}
+	case strings.HasPrefix(err.Error(), "yaml: "):
+		return &SyntaxError{Filename: filename, Line: matchLine(yamlLineRegexp, err.Error()), Err: err}
+	case tomlTypeRegexp.MatchString(err.Error()):
+		m := tomlTypeRegexp.FindStringSubmatch(err.Error())
+		line, _ := strconv.Atoi(m[1])
+		return &TypeMismatchError{Filename: filename, Line: line, Field: m[2], Err: err}
+	}
+
+	return err
+}
+
+func matchLine(re *regexp.Regexp, msg string) int {
+	m := re.FindStringSubmatch(msg)
+	if m == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(m[1])
+
+	return line
+}
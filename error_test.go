@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrFileNotFound(t *testing.T) {
+	_, err := WithFile("testdata/config.test.a.yaml")
+	var nfErr ErrFileNotFound
+	require.ErrorAs(t, err, &nfErr)
+	require.Equal(t, "testdata/config.test.a.yaml", nfErr.Filename)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	require.EqualError(t, err, "config file not found: \"testdata/config.test.a.yaml\"")
+
+	_, err = NewLayered([]Source{FileSource("testdata/config.test.a.json")})
+	require.ErrorAs(t, err, &nfErr)
+
+	err = LoadConfig(&testConfig{}, "", nil)
+	require.ErrorIs(t, err, ErrMissingTemplate)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	type decodeTest struct {
+		App struct {
+			Port int `yaml:"port" json:"port" toml:"port"`
+		} `yaml:"app" json:"app" toml:"app"`
+	}
+
+	tests := []struct {
+		name    string
+		cfgType Type
+		data    string
+		syntax  bool
+		line    int
+		column  int
+		field   string
+		message string
+	}{
+		{
+			name:    "json syntax",
+			cfgType: JSONConfig,
+			data:    "{\n  \"app\": {\"port\": 1,}\n}",
+			syntax:  true,
+			line:    2,
+			column:  22,
+			message: "decode line 2, column 22: invalid character '}' looking for beginning of object key string",
+		},
+		{
+			name:    "json type",
+			cfgType: JSONConfig,
+			data:    "{\n  \"app\": {\"port\": \"80\"}\n}",
+			line:    2,
+			column:  23,
+			field:   "app.port",
+			message: "decode line 2, column 23: json: cannot unmarshal string into Go struct field decodeTest.app.port of type int",
+		},
+		{
+			name:    "yaml syntax",
+			cfgType: YamlConfig,
+			data:    "app:\n  port: [80\n",
+			syntax:  true,
+			line:    1,
+			message: "decode yaml: line 1: did not find expected ',' or ']'",
+		},
+		{
+			name:    "yaml type",
+			cfgType: YamlConfig,
+			data:    "app:\n  port: eighty\n",
+			line:    2,
+			message: "decode yaml: unmarshal errors:\n  line 2: cannot unmarshal !!str `eighty` into int",
+		},
+		{
+			name:    "toml syntax",
+			cfgType: TomlConfig,
+			data:    "[app]\nport = \n",
+			syntax:  true,
+			line:    2,
+			column:  8,
+		},
+		{
+			name:    "toml type",
+			cfgType: TomlConfig,
+			data:    "[app]\nport = \"80\"\n",
+			line:    2,
+			field:   "app.port",
+			message: "decode toml: line 2 (last key \"app.port\"): incompatible types: TOML value has type string; destination has type integer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewLayered([]Source{BytesSource(tt.cfgType, []byte(tt.data))})
+			require.NoError(t, err)
+
+			err = cfg.LoadConfig(&decodeTest{}, nil)
+			require.Error(t, err)
+			if tt.message != "" {
+				require.EqualError(t, err, tt.message)
+			}
+
+			var sErr *SyntaxError
+			var tErr *TypeMismatchError
+			if tt.syntax {
+				require.ErrorAs(t, err, &sErr)
+				require.Equal(t, tt.line, sErr.Line)
+				require.Equal(t, tt.column, sErr.Column)
+				require.False(t, errors.As(err, &tErr))
+				return
+			}
+			require.ErrorAs(t, err, &tErr)
+			require.Equal(t, tt.line, tErr.Line)
+			require.Equal(t, tt.column, tErr.Column)
+			require.Equal(t, tt.field, tErr.Field)
+			require.False(t, errors.As(err, &sErr))
+		})
+	}
+
+	t.Run("filename", func(t *testing.T) {
+		err := LoadConfig(&testConfig{}, "testdata/config.test.env.yaml", nil)
+		var tErr *TypeMismatchError
+		require.ErrorAs(t, err, &tErr)
+		require.Equal(t, "testdata/config.test.env.yaml", tErr.Filename)
+		require.Contains(t, err.Error(), "decode testdata/config.test.env.yaml: yaml: unmarshal errors:")
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("app:\n    name: app\ntags: [a]\ninterval: 1s\n"))})
+		require.NoError(t, err)
+
+		var vErr *ValidationError
+		require.ErrorAs(t, cfg.LoadConfig(&validatedConfig{}, nil), &vErr)
+		require.Equal(t, "app.port", vErr.Path)
+	})
+}
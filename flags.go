@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+
+	"github.com/rottendev/config/pkg"
+)
+
+// Flags binds the fields of a config struct to the flags of a flag.FlagSet, see BindFlags.
+type Flags struct {
+	fs     *flag.FlagSet
+	fields map[string]*flagValue // The bound flags by name.
+}
+
+// flagValue is the flag.Value of a field, holding the raw command-line value.
+type flagValue struct {
+	index  []int        // The index sequence of the field, see reflect.Value.FieldByIndex.
+	typ    reflect.Type // The type of the field.
+	value  string       // The default, then the command-line value.
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	return v.value
+}
+
+// Set checks that s can be parsed into the field before keeping it.
+func (v *flagValue) Set(s string) error {
+	if err := pkg.SetString(reflect.New(v.typ).Elem(), s); err != nil {
+		return err
+	}
+	v.value = s
+
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// BindFlags registers a flag on fs for every field of conf, a pointer to a config struct.
+// Flags are named after the dotted key path of the field, e.g. --app.port for App.Port, unless
+// the field has a flag tag; fields tagged flag:"-" are not bound. The desc or comment tag of a
+// field is the usage of its flag and its default tag the default shown. Flag values are parsed
+// like environment variables, see pkg.SetString.
+//
+// The flags set on the command line override the loaded configuration when the binder is
+// given to WithFlags, or with Apply once fs is parsed.
+func BindFlags(fs *flag.FlagSet, conf interface{}) (*Flags, error) {
+	t := reflect.TypeOf(conf)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("flags: expected a pointer to a struct")
+	}
+
+	f := &Flags{fs: fs, fields: make(map[string]*flagValue)}
+	if err := f.bind(t.Elem(), "", nil); err != nil {
+		return nil, fmt.Errorf("flags: %w", err)
+	}
+
+	return f, nil
+}
+
+func (f *Flags) bind(t reflect.Type, path string, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if pkg.IsIgnored(field) {
+			continue
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		ft := pkg.DerefType(field.Type)
+		if pkg.IsInline(field) {
+			// The fields of unexported embedded structs cannot be set.
+			if !field.IsExported() {
+				continue
+			}
+			if err := f.bind(ft, path, fieldIndex); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := pkg.JoinPath(path, pkg.FieldKey(field))
+		if ft.Kind() == reflect.Struct && !pkg.IsTextUnmarshaler(ft) {
+			if field.Tag.Get("flag") == "-" {
+				continue
+			}
+			if err := f.bind(ft, name, fieldIndex); err != nil {
+				return err
+			}
+			continue
+		}
+		if !settable(ft) {
+			continue
+		}
+
+		if tag := field.Tag.Get("flag"); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		if f.fs.Lookup(name) != nil {
+			return fmt.Errorf("flag %q already defined", name)
+		}
+
+		v := &flagValue{
+			index:  fieldIndex,
+			typ:    field.Type,
+			value:  field.Tag.Get("default"),
+			isBool: ft.Kind() == reflect.Bool,
+		}
+		f.fs.Var(v, name, pkg.FieldDescription(field))
+		f.fields[name] = v
+	}
+
+	return nil
+}
+
+// settable reports whether values of t can be parsed from a flag by pkg.SetString.
+func settable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128,
+		reflect.UnsafePointer, reflect.Array:
+		return false
+	}
+
+	return true
+}
+
+// Apply sets the fields of conf, a pointer to the struct given to BindFlags, bound to the
+// flags set on the command line. Nil pointers to structs holding such fields are allocated.
+func (f *Flags) Apply(conf interface{}) error {
+	val := reflect.ValueOf(conf)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return errors.New("flags: expected a non-nil pointer to a struct")
+	}
+
+	var err error
+	f.fs.Visit(func(fl *flag.Flag) {
+		v, ok := f.fields[fl.Name]
+		if !ok || err != nil {
+			return
+		}
+		if sErr := pkg.SetString(fieldByIndex(val.Elem(), v.index), v.value); sErr != nil {
+			err = fmt.Errorf("flag --%s: %w", fl.Name, sErr)
+		}
+	})
+
+	return err
+}
+
+// fieldByIndex returns the nested field of v at index, allocating the nil struct pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 {
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					v.Set(reflect.New(v.Type().Elem()))
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type flagConfig struct {
+	App struct {
+		Name string `yaml:"name" default:"app" desc:"The application name."`
+		Port int    `yaml:"port" default:"8080"`
+	} `yaml:"app"`
+	Debug   bool          `yaml:"debug"`
+	Timeout time.Duration `yaml:"timeout" default:"5s" flag:"timeout"`
+	Modules []string      `yaml:"modules"`
+	Cache   *struct {
+		Size int `yaml:"size"`
+	} `yaml:"cache"`
+	Token  string      `yaml:"token" flag:"-"`
+	Extras interface{} `yaml:"extras"`
+}
+
+func TestBindFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f, err := BindFlags(fs, &flagConfig{})
+	require.NoError(t, err)
+
+	var names []string
+	fs.VisitAll(func(fl *flag.Flag) { names = append(names, fl.Name) })
+	require.Equal(t, []string{"app.name", "app.port", "cache.size", "debug", "modules", "timeout"}, names)
+	require.Equal(t, "The application name.", fs.Lookup("app.name").Usage)
+	require.Equal(t, "8080", fs.Lookup("app.port").DefValue)
+
+	require.NoError(t, fs.Parse([]string{"--app.port=9090", "--debug", "--timeout", "1m", "--modules", "a,b", "--cache.size=3"}))
+	setting := flagConfig{}
+	setting.App.Name = "loaded"
+	require.NoError(t, f.Apply(&setting))
+	require.Equal(t, "loaded", setting.App.Name, "unset flags must not override the configuration")
+	require.Equal(t, 9090, setting.App.Port)
+	require.True(t, setting.Debug)
+	require.Equal(t, time.Minute, setting.Timeout)
+	require.Equal(t, []string{"a", "b"}, setting.Modules)
+	require.NotNil(t, setting.Cache)
+	require.Equal(t, 3, setting.Cache.Size)
+
+	t.Run("invalid value", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(&bytes.Buffer{})
+		_, err := BindFlags(fs, &flagConfig{})
+		require.NoError(t, err)
+		require.ErrorContains(t, fs.Parse([]string{"--app.port=http"}), "invalid value")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := BindFlags(flag.NewFlagSet("test", flag.ContinueOnError), flagConfig{})
+		require.Error(t, err)
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Bool("debug", false, "")
+		_, err = BindFlags(fs, &flagConfig{})
+		require.ErrorContains(t, err, `flag "debug" already defined`)
+	})
+}
+
+func TestConfig_Flags(t *testing.T) {
+	resetEnv()
+	t.Setenv("APP_APP_PORT", "7070")
+	t.Setenv("APP_APP_NAME", "env")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f, err := BindFlags(fs, &flagConfig{})
+	require.NoError(t, err)
+	require.NoError(t, fs.Parse([]string{"--app.port", "9090"}))
+
+	cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("app:\n  port: 8081\ndebug: true\n"))},
+		WithEnvOverrides("APP", "_"), WithFlags(f))
+	require.NoError(t, err)
+
+	setting := flagConfig{}
+	require.NoError(t, cfg.LoadConfig(&setting, nil))
+	require.Equal(t, 9090, setting.App.Port, "flags override the environment")
+	require.Equal(t, "env", setting.App.Name)
+	require.True(t, setting.Debug)
+}
@@ -0,0 +1,86 @@
+package config
+
+import "github.com/rottendev/config/provider"
+
+// Option configures a Config.
+type Option func(*Config)
+
+// WithEnvOverrides overrides decoded values with environment variables named after the field
+// path, e.g. APP_APP_PORT for App.Port with the prefix APP. The separator defaults to "_".
+func WithEnvOverrides(prefix, separator string) Option {
+	return func(c *Config) {
+		if separator == "" {
+			separator = "_"
+		}
+		c.envOverrides = true
+		c.envPrefix = prefix
+		c.envSeparator = separator
+	}
+}
+
+// WithEnvLookup configures the variable lookup of the env sources of the config, see
+// provider.EnvProvider: the layers in precedence order, provider.DefaultEnvLayers when empty,
+// the variables of the provider.VarsLayer and whether the .env variables missing from the
+// process environment are exported to it.
+func WithEnvLookup(layers []provider.EnvLayer, vars map[string]string, export bool) Option {
+	return func(c *Config) {
+		c.envLookup = true
+		c.envLayers = layers
+		c.envVars = vars
+		c.envExport = export
+	}
+}
+
+// WithStrict enables strict decoding: keys without a matching struct field are
+// reported as an *UnknownFieldsError instead of being ignored.
+func WithStrict(strict bool) Option {
+	return func(c *Config) {
+		c.strict = strict
+	}
+}
+
+// WithInterpolation resolves ${env:NAME}, ${file:PATH} and ${key.path} references in the
+// string values of every source once they are decoded, see pkg.Interpolate.
+func WithInterpolation(interpolate bool) Option {
+	return func(c *Config) {
+		c.interpolate = interpolate
+	}
+}
+
+// WithSecretResolver resolves the secret references of backend with r, e.g.
+// secret://vault/db#password with the backend "vault". The "file" backend defaults to
+// a FileSecretResolver reading DefaultSecretDir.
+func WithSecretResolver(backend string, r SecretResolver) Option {
+	return func(c *Config) {
+		if c.secretResolvers == nil {
+			c.secretResolvers = make(map[string]SecretResolver)
+		}
+		c.secretResolvers[backend] = r
+	}
+}
+
+// WithEncryptionKey decrypts the ENC[...] values of every source with key, see EncryptValue.
+// Without it, the key is read from the DefaultKeyEnv variable when an encrypted value is found.
+func WithEncryptionKey(key []byte) Option {
+	return func(c *Config) {
+		c.encryptionKey = key
+	}
+}
+
+// WithSchema checks the raw documents of the sources against the JSON Schema schema before
+// decoding them, e.g. one returned by GenerateSchema. The documents are merged in order, so
+// later sources may complete earlier ones, and every violation is reported in a *SchemaError.
+// YAML, JSON, TOML and env template sources are supported.
+func WithSchema(schema []byte) Option {
+	return func(c *Config) {
+		c.schema = schema
+	}
+}
+
+// WithFlags overrides the decoded values and the environment overrides with the flags of f
+// set on the command line, see BindFlags. The flag set must be parsed before loading.
+func WithFlags(f *Flags) Option {
+	return func(c *Config) {
+		c.flags = f
+	}
+}
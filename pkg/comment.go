@@ -0,0 +1,171 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldComment returns the documentation of a struct field rendered in sample files: its desc
+// or comment tag, followed by a line with the required marker, the default value and the values
+// allowed by its validate tag. It is empty for fields without description nor validation rules.
+func FieldComment(field reflect.StructField) string {
+	desc := FieldDescription(field)
+
+	var notes []string
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			notes = append(notes, "Required.")
+		case "oneof":
+			notes = append(notes, "Allowed: "+strings.Join(strings.Fields(param), ", ")+".")
+		}
+	}
+	if desc == "" && len(notes) == 0 {
+		return ""
+	}
+	if def, ok := field.Tag.Lookup("default"); ok {
+		notes = append(notes, "Default: "+def+".")
+	}
+
+	lines := make([]string, 0, 2)
+	if desc != "" {
+		lines = append(lines, desc)
+	}
+	if len(notes) > 0 {
+		lines = append(lines, strings.Join(notes, " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// FieldDescription returns the desc tag of a struct field, or its comment tag.
+func FieldDescription(field reflect.StructField) string {
+	if desc, ok := field.Tag.Lookup("desc"); ok {
+		return desc
+	}
+
+	return field.Tag.Get("comment")
+}
+
+// CommentYAML sets the FieldComment of the fields of t as the head comments of their keys
+// in node, a document or mapping node encoded from a value of type t.
+func CommentYAML(node *yaml.Node, t reflect.Type) {
+	if node.Kind == yaml.DocumentNode {
+		for _, n := range node.Content {
+			CommentYAML(n, t)
+		}
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, n := range node.Content {
+			CommentYAML(n, t.Elem())
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			field, ok := yamlField(t, node.Content[i].Value)
+			if !ok {
+				continue
+			}
+			if c := FieldComment(field); c != "" {
+				node.Content[i].HeadComment = c
+			}
+			CommentYAML(node.Content[i+1], field.Type)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 1; i < len(node.Content); i += 2 {
+			CommentYAML(node.Content[i], t.Elem())
+		}
+	}
+}
+
+// yamlField returns the field of t encoded under key, looking into inline structs. The key is
+// either the one of yaml.v3 or the FieldKey of the field, used by the env templates.
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if IsIgnored(field) {
+			continue
+		}
+		if IsInline(field) {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if f, ok := yamlField(ft, key); ok {
+				return f, true
+			}
+			if _, opts := ParseTag(field, "yaml"); hasOption(opts, "inline") {
+				continue
+			}
+		}
+
+		yamlName, _ := ParseTag(field, "yaml")
+		if yamlName == "" {
+			yamlName = strings.ToLower(field.Name)
+		}
+		if key == FieldKey(field) || key == yamlName {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// EnvComments returns the FieldComment of the fields of t by the variable names of
+// GeneratePlaceholderMap with the same prefix.
+func EnvComments(t reflect.Type, prefix string) map[string]string {
+	comments := make(map[string]string)
+	envComments(t, prefix, comments)
+
+	return comments
+}
+
+func envComments(t reflect.Type, prefix string, comments map[string]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := FieldEnvName(field)
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			envComments(field.Type, prefix, comments)
+			continue
+		}
+		if ft := DerefType(field.Type); ft.Kind() == reflect.Struct && !IsTextUnmarshaler(ft) {
+			envComments(ft, prefix+name+"_", comments)
+			continue
+		}
+		if c := FieldComment(field); c != "" {
+			comments[prefix+name] = c
+		}
+	}
+}
+
+// CommentLines prefixes every line of comment with "# " and indent.
+func CommentLines(comment, indent string) string {
+	if comment == "" {
+		return ""
+	}
+
+	b := strings.Builder{}
+	for _, line := range strings.Split(comment, "\n") {
+		b.WriteString(indent + "# " + line + "\n")
+	}
+
+	return b.String()
+}
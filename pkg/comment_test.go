@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestFieldComment(t *testing.T) {
+	type testStruct struct {
+		Plain    string `default:"x"`
+		Desc     string `desc:"The description." default:"x"`
+		Comment  string `comment:"The comment."`
+		Required string `validate:"required,min=1"`
+		OneOf    string `desc:"The mode." validate:"oneof=dev prod" default:"dev"`
+	}
+
+	want := map[string]string{
+		"Plain":    "",
+		"Desc":     "The description.\nDefault: x.",
+		"Comment":  "The comment.",
+		"Required": "Required.",
+		"OneOf":    "The mode.\nAllowed: dev, prod. Default: dev.",
+	}
+	typ := reflect.TypeOf(testStruct{})
+	for i := 0; i < typ.NumField(); i++ {
+		if got := FieldComment(typ.Field(i)); got != want[typ.Field(i).Name] {
+			t.Errorf("FieldComment(%s) = %q, want %q", typ.Field(i).Name, got, want[typ.Field(i).Name])
+		}
+	}
+
+	if got := CommentLines("a\nb", "  "); got != "  # a\n  # b\n" {
+		t.Errorf("CommentLines() = %q", got)
+	}
+}
+
+func TestCommentYAML(t *testing.T) {
+	type server struct {
+		Host string `yaml:"host" desc:"The host."`
+	}
+	type base struct {
+		Debug bool `yaml:"debug" desc:"Enable debug logs."`
+	}
+	type testStruct struct {
+		base    `yaml:",inline"`
+		Servers []server `yaml:"servers" desc:"The servers."`
+		Name    string   `desc:"The name."`
+	}
+
+	var node yaml.Node
+	if err := node.Encode(testStruct{Servers: []server{{Host: "a"}}, Name: "app"}); err != nil {
+		t.Fatal(err)
+	}
+	CommentYAML(&node, reflect.TypeOf(&testStruct{}))
+
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Enable debug logs.
+debug: false
+# The servers.
+servers:
+    - # The host.
+      host: a
+# The name.
+name: app
+`
+	if string(out) != want {
+		t.Errorf("CommentYAML() =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestEnvComments(t *testing.T) {
+	type testStruct struct {
+		App struct {
+			Port int `desc:"The port."`
+		}
+		DatabaseURL string    `env:"DB_URL" validate:"required"`
+		Ignored     string    `env:"-" desc:"Ignored."`
+		StartedAt   time.Time `desc:"The start time."`
+		DB          *struct {
+			Host string `desc:"The database host."`
+		}
+	}
+
+	got := EnvComments(reflect.TypeOf(&testStruct{}), "SVC_")
+	want := map[string]string{
+		"SVC_APP_PORT":   "The port.",
+		"SVC_DB_URL":     "Required.",
+		"SVC_STARTED_AT": "The start time.",
+		"SVC_DB_HOST":    "The database host.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvComments() = %v, want %v", got, want)
+	}
+}
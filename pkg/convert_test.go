@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetString(t *testing.T) {
+	type inner struct {
+		A int `json:"a"`
+	}
+	type target struct {
+		S   string
+		B   bool
+		I   int
+		I8  int8
+		U   uint16
+		F   float64
+		D   time.Duration
+		P   *int
+		IP  net.IP
+		L   []string
+		LI  []int
+		LJ  []string
+		M   map[string]int
+		MJ  map[string]string
+		St  inner
+		Any interface{}
+	}
+
+	tests := []struct {
+		field string
+		in    string
+		want  interface{}
+	}{
+		{"S", "text", "text"},
+		{"B", "true", true},
+		{"I", "-42", -42},
+		{"I8", "0x10", int8(16)},
+		{"U", "8080", uint16(8080)},
+		{"F", "1.5", 1.5},
+		{"D", "1m30s", 90 * time.Second},
+		{"IP", "127.0.0.1", net.ParseIP("127.0.0.1")},
+		{"L", "a, b,c", []string{"a", "b", "c"}},
+		{"LI", "1,2", []int{1, 2}},
+		{"LJ", `["a,b","c"]`, []string{"a,b", "c"}},
+		{"M", "a=1, b=2", map[string]int{"a": 1, "b": 2}},
+		{"MJ", `{"a":"b"}`, map[string]string{"a": "b"}},
+		{"St", `{"a":3}`, inner{A: 3}},
+		{"Any", `{"a":"b"}`, map[string]interface{}{"a": "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			v := target{}
+			field := reflect.ValueOf(&v).Elem().FieldByName(tt.field)
+			if err := SetString(field, tt.in); err != nil {
+				t.Fatalf("SetString() error = %v", err)
+			}
+			if got := field.Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SetString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("pointer", func(t *testing.T) {
+		v := target{}
+		if err := SetString(reflect.ValueOf(&v).Elem().FieldByName("P"), "7"); err != nil {
+			t.Fatal(err)
+		}
+		if v.P == nil || *v.P != 7 {
+			t.Errorf("SetString() = %v, want 7", v.P)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		v := target{}
+		val := reflect.ValueOf(&v).Elem()
+		for field, in := range map[string]string{"B": "yes!", "I8": "300", "D": "1 minute", "M": "a", "LI": "1,x"} {
+			if err := SetString(val.FieldByName(field), in); err == nil {
+				t.Errorf("SetString(%s, %q) expected an error", field, in)
+			}
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// LookupFunc returns the value of an environment variable and whether it is set.
+type LookupFunc func(name string) (string, bool)
+
+// EnvName returns the environment variable name of a Go field name, e.g. FILES_DIR for FilesDir.
+func EnvName(name string) string {
+	return strings.ToUpper(ToSnakeCase(name))
+}
+
+// FieldEnvName returns the environment variable name part of a struct field: the name of
+// its env tag, the upper case field name for nested structs, e.g. FILESCONF for FilesConf,
+// or the EnvName of the field name. It is "-" for ignored and unexported fields, and empty
+// for inline fields, see IsInline.
+func FieldEnvName(field reflect.StructField) string {
+	if name, _ := ParseTag(field, "env"); name != "" {
+		return name
+	}
+	if IsIgnored(field) {
+		return "-"
+	}
+	if IsInline(field) {
+		return ""
+	}
+	if t := DerefType(field.Type); t.Kind() == reflect.Struct && !IsTextUnmarshaler(t) {
+		return strings.ToUpper(field.Name)
+	}
+
+	return EnvName(field.Name)
+}
+
+// ApplyEnv overrides the fields of the struct pointed to by v with the environment variables
+// derived from their path: the prefix and the FieldEnvName of every field joined by sep,
+// e.g. APP_APP_PORT for App.Port with the prefix APP and the separator "_".
+// Variables set to an empty string and fields tagged env:"-" are ignored.
+func ApplyEnv(v interface{}, prefix, sep string, lookup LookupFunc) error {
+	_, err := applyEnv(v, prefix, sep, lookup)
+
+	return err
+}
+
+// DecodeEnv populates the struct pointed to by v from the environment alone. Variable names
+// are built like the keys of GeneratePlaceholderMap: the prefix followed by the FieldEnvName
+// of every field joined by "_", e.g. APP_FILES_DIR for FilesDir with the prefix APP_.
+// Strings are parsed with SetString; unset and empty variables leave the field unchanged.
+func DecodeEnv(v interface{}, prefix string, lookup LookupFunc) error {
+	_, err := applyEnv(v, "", "_", func(name string) (string, bool) {
+		return lookup(prefix + name)
+	})
+
+	return err
+}
+
+// applyEnv returns the number of fields set from the environment.
+func applyEnv(v interface{}, prefix, sep string, lookup LookupFunc) (int, error) {
+	set := 0
+	// The variable names of the visited structs, by their Go field path.
+	names := make(map[string]string)
+	err := Walk(v, func(f Field) error {
+		part := FieldEnvName(f.Struct)
+		if part == "-" {
+			return ErrSkipStruct
+		}
+		// Inline structs share the name of their parent.
+		name := names[strings.Join(f.Names[:len(f.Names)-1], ".")]
+		if part != "" {
+			name = joinEnv(name, prefix, sep, part)
+		}
+		names[strings.Join(f.Names, ".")] = name
+
+		if _, ok := structValue(f.Value); ok {
+			return nil
+		}
+		if f.Value.Kind() == reflect.Ptr && f.Value.IsNil() {
+			if _, ok := structValue(reflect.New(f.Value.Type().Elem())); ok {
+				// Only keep a nil struct pointer allocated when one of its fields is set.
+				alloc := reflect.New(f.Value.Type().Elem())
+				allocPrefix := name
+				if allocPrefix == "" {
+					allocPrefix = prefix
+				}
+				n, err := applyEnv(alloc.Interface(), allocPrefix, sep, lookup)
+				if err != nil {
+					return err
+				}
+				if n > 0 {
+					f.Value.Set(alloc)
+					set += n
+				}
+				return ErrSkipStruct
+			}
+		}
+
+		value, ok := lookup(name)
+		if !ok || value == "" {
+			return nil
+		}
+		if err := SetString(f.Value, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		set++
+
+		return nil
+	})
+
+	return set, err
+}
+
+// joinEnv appends part to the variable name of the parent struct with sep,
+// starting from prefix for top-level fields.
+func joinEnv(parent, prefix, sep, part string) string {
+	if parent == "" {
+		parent = prefix
+	}
+	if parent == "" {
+		return part
+	}
+
+	return parent + sep + part
+}
@@ -0,0 +1,173 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+	}
+	type testStruct struct {
+		App struct {
+			Name string
+			Port int
+		}
+		FilesDir  string
+		FilesConf struct {
+			Dir string
+		}
+		Primary *server
+		Replica *server
+		Modules []string
+	}
+
+	env := map[string]string{
+		"APP_APP_PORT":      "9090",
+		"APP_FILES_DIR":     "/tmp",
+		"APP_FILESCONF_DIR": "/data",
+		"APP_PRIMARY_HOST":  "db",
+		"APP_MODULES":       "a,b",
+		"APP_APP_NAME":      "",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	test := testStruct{}
+	test.App.Name = "app"
+	if err := ApplyEnv(&test, "APP", "_", lookup); err != nil {
+		t.Fatal(err)
+	}
+	if test.App.Port != 9090 || test.App.Name != "app" || test.FilesDir != "/tmp" || test.FilesConf.Dir != "/data" {
+		t.Errorf("ApplyEnv() = %+v", test)
+	}
+	if test.Primary == nil || test.Primary.Host != "db" {
+		t.Errorf("ApplyEnv() Primary = %+v", test.Primary)
+	}
+	if test.Replica != nil {
+		t.Errorf("ApplyEnv() Replica = %+v, want nil", test.Replica)
+	}
+	if len(test.Modules) != 2 {
+		t.Errorf("ApplyEnv() Modules = %v", test.Modules)
+	}
+
+	env = map[string]string{"APP__PORT": "x"}
+	if err := ApplyEnv(&test, "", "__", lookup); err == nil || err.Error() != `APP__PORT: strconv.ParseInt: parsing "x": invalid syntax` {
+		t.Errorf("ApplyEnv() error = %v", err)
+	}
+}
+
+func TestDecodeEnv(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+	}
+	type testStruct struct {
+		App struct {
+			Name    string
+			Timeout time.Duration
+		}
+		DatabaseURL string `env:"DB_URL"`
+		Debug       bool
+		Ignored     string `env:"-"`
+		Servers     []server
+		Labels      map[string]int
+		Backup      server `env:"REPLICA"`
+	}
+
+	env := map[string]string{
+		"SVC_APP_NAME":     "svc",
+		"SVC_APP_TIMEOUT":  "5s",
+		"SVC_DB_URL":       "postgres://db",
+		"SVC_DEBUG":        "true",
+		"SVC_IGNORED":      "set",
+		"SVC_SERVERS":      `[{"Host":"a","Port":1}]`,
+		"SVC_LABELS":       "a=1,b=2",
+		"SVC_REPLICA_HOST": "replica",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	test := testStruct{}
+	if err := DecodeEnv(&test, "SVC_", lookup); err != nil {
+		t.Fatal(err)
+	}
+	if test.App.Name != "svc" || test.App.Timeout != 5*time.Second || test.DatabaseURL != "postgres://db" || !test.Debug {
+		t.Errorf("DecodeEnv() = %+v", test)
+	}
+	if test.Ignored != "" {
+		t.Errorf("DecodeEnv() Ignored = %q, want empty", test.Ignored)
+	}
+	if !reflect.DeepEqual(test.Servers, []server{{Host: "a", Port: 1}}) {
+		t.Errorf("DecodeEnv() Servers = %+v", test.Servers)
+	}
+	if !reflect.DeepEqual(test.Labels, map[string]int{"a": 1, "b": 2}) {
+		t.Errorf("DecodeEnv() Labels = %v", test.Labels)
+	}
+	if test.Backup.Host != "replica" {
+		t.Errorf("DecodeEnv() Backup = %+v", test.Backup)
+	}
+
+	env = map[string]string{"SVC_DEBUG": "maybe"}
+	if err := DecodeEnv(&test, "SVC_", lookup); err == nil || err.Error() != `DEBUG: strconv.ParseBool: parsing "maybe": invalid syntax` {
+		t.Errorf("DecodeEnv() error = %v", err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	for in, want := range map[string]string{"Port": "PORT", "FilesDir": "FILES_DIR", "HTTPServer": "HTTP_SERVER"} {
+		if got := EnvName(in); got != want {
+			t.Errorf("EnvName(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestFieldEnvName(t *testing.T) {
+	type conf struct {
+		Dir string
+	}
+	type testStruct struct {
+		FilesDir  string
+		FilesConf conf
+		Replica   *conf `env:"DB_REPLICA"`
+		Backup    *conf
+		StartedAt time.Time
+		Ignored   string `env:"-"`
+		conf
+	}
+
+	typ := reflect.TypeOf(testStruct{})
+	for name, want := range map[string]string{
+		"FilesDir":  "FILES_DIR",
+		"FilesConf": "FILESCONF",
+		"Replica":   "DB_REPLICA",
+		"Backup":    "BACKUP",
+		"StartedAt": "STARTED_AT",
+		"Ignored":   "-",
+		"conf":      "",
+	} {
+		field, _ := typ.FieldByName(name)
+		if got := FieldEnvName(field); got != want {
+			t.Errorf("FieldEnvName(%s) = %q, want %q", name, got, want)
+		}
+	}
+
+	// Templates and env decoding name nested structs alike.
+	keys := make(map[string]interface{})
+	GeneratePlaceholderMap(&struct{ FilesConf conf }{}, keys, "")
+	if _, ok := keys["FILESCONF_DIR"]; !ok {
+		t.Errorf("GeneratePlaceholderMap() keys = %v, want FILESCONF_DIR", keys)
+	}
+	test := testStruct{}
+	lookup := func(name string) (string, bool) { return "/data", name == "FILESCONF_DIR" }
+	if err := DecodeEnv(&test, "", lookup); err != nil || test.FilesConf.Dir != "/data" {
+		t.Errorf("DecodeEnv() = %+v, %v", test, err)
+	}
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnsetVariable is a template variable without a value nor a default.
+type UnsetVariable struct {
+	Name    string // The variable name.
+	Message string // The message of a ${NAME:?message} expression, empty otherwise.
+	Line    int    // The 1-based line of the expression.
+}
+
+func (v UnsetVariable) String() string {
+	s := fmt.Sprintf("%s (line %d)", v.Name, v.Line)
+	if v.Message != "" {
+		s += ": " + v.Message
+	}
+
+	return s
+}
+
+// UnsetVariablesError lists every variable Expand could not resolve.
+type UnsetVariablesError struct {
+	Variables []UnsetVariable
+}
+
+func (e *UnsetVariablesError) Error() string {
+	vars := make([]string, 0, len(e.Variables))
+	for _, v := range e.Variables {
+		vars = append(vars, v.String())
+	}
+
+	return "unset variables: " + strings.Join(vars, ", ")
+}
+
+// Expand replaces the variables of s like a POSIX shell:
+//
+//	$NAME, ${NAME}     the value of NAME, which must be set
+//	${NAME:-word}      word when NAME is unset or empty
+//	${NAME:?message}   an error with message when NAME is unset or empty
+//	${NAME:+word}      word when NAME is set and not empty, an empty string otherwise
+//	$$                 a literal $
+//
+// Without the colon, -, ? and + only test whether NAME is unset. Words are expanded too.
+// Every unset variable without a default is reported in a single *UnsetVariablesError.
+func Expand(s string, lookup LookupFunc) (string, error) {
+	e := expander{lookup: lookup, line: 1}
+	out, err := e.expand(s)
+	if err != nil {
+		return "", err
+	}
+	if len(e.unset) > 0 {
+		return "", &UnsetVariablesError{Variables: e.unset}
+	}
+
+	return out, nil
+}
+
+type expander struct {
+	lookup LookupFunc
+	line   int
+	unset  []UnsetVariable
+}
+
+func (e *expander) expand(s string) (string, error) {
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			if s[i] == '\n' {
+				e.line++
+			}
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("line %d: unterminated ${ expression", e.line)
+			}
+			line := e.line
+			value, err := e.expression(s[i+2:end], line)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			e.line = line + strings.Count(s[i:end], "\n")
+			i = end
+		case isNameStart(next):
+			end := i + 2
+			for end < len(s) && isNameChar(s[end]) {
+				end++
+			}
+			b.WriteString(e.value(s[i+1:end], e.line))
+			i = end - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+
+	return b.String(), nil
+}
+
+// expression resolves the content of a ${...} expression found on line.
+func (e *expander) expression(expr string, line int) (string, error) {
+	n := 0
+	for n < len(expr) && isNameChar(expr[n]) {
+		n++
+	}
+	name, rest := expr[:n], expr[n:]
+	if name == "" || !isNameStart(name[0]) {
+		return "", fmt.Errorf("line %d: invalid variable name in ${%s}", line, expr)
+	}
+	if rest == "" {
+		return e.value(name, line), nil
+	}
+
+	colon := strings.HasPrefix(rest, ":")
+	if colon {
+		rest = rest[1:]
+	}
+	if rest == "" || !strings.ContainsRune("-?+", rune(rest[0])) {
+		return "", fmt.Errorf("line %d: invalid operator in ${%s}", line, expr)
+	}
+	op, word := rest[0], rest[1:]
+
+	value, ok := e.lookup(name)
+	set := ok && (!colon || value != "")
+	switch op {
+	case '-':
+		if set {
+			return value, nil
+		}
+		return e.word(word, line)
+	case '?':
+		if set {
+			return value, nil
+		}
+		msg, err := e.word(word, line)
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			msg = "required"
+		}
+		e.unset = append(e.unset, UnsetVariable{Name: name, Message: msg, Line: line})
+		return "", nil
+	default:
+		if set {
+			return e.word(word, line)
+		}
+		return "", nil
+	}
+}
+
+// word expands the word of an expression, keeping the line of the outer template.
+func (e *expander) word(word string, line int) (string, error) {
+	saved := e.line
+	e.line = line
+	defer func() { e.line = saved }()
+
+	return e.expand(word)
+}
+
+// value returns the value of a variable, recording it as unset when missing.
+func (e *expander) value(name string, line int) string {
+	value, ok := e.lookup(name)
+	if !ok {
+		e.unset = append(e.unset, UnsetVariable{Name: name, Line: line})
+	}
+
+	return value
+}
+
+// closingBrace returns the index of the brace closing the expression starting at start,
+// skipping nested expressions, or -1.
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	env := map[string]string{
+		"NAME":  "app",
+		"EMPTY": "",
+		"PORT":  "8080",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "braced", in: "name: ${NAME}", want: "name: app"},
+		{name: "bare", in: "name: $NAME-$PORT", want: "name: app-8080"},
+		{name: "empty", in: "name: ${EMPTY}", want: "name: "},
+		{name: "default unset", in: "${MISSING:-def}", want: "def"},
+		{name: "default empty", in: "${EMPTY:-def}", want: "def"},
+		{name: "default set", in: "${NAME:-def}", want: "app"},
+		{name: "default without colon", in: "${EMPTY-def}|${MISSING-def}", want: "|def"},
+		{name: "nested default", in: "${MISSING:-${NAME}:${PORT}}", want: "app:8080"},
+		{name: "required set", in: "${NAME:?name is required}", want: "app"},
+		{name: "alternative", in: "${NAME:+on}|${EMPTY:+on}|${MISSING:+on}", want: "on||"},
+		{name: "alternative without colon", in: "${EMPTY+on}", want: "on"},
+		{name: "escape", in: "cost: $$5 $${NAME}", want: "cost: $5 ${NAME}"},
+		{name: "lone dollar", in: "a $ b $1 $", want: "a $ b $1 $"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.in, lookup)
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		return "", name == "EMPTY"
+	}
+
+	_, err := Expand("a: ${A}\nb: $B\nc: ${EMPTY:?c is required}\nd: ${D:-x}\ne: ${E:?}\n", lookup)
+	var uErr *UnsetVariablesError
+	if !errors.As(err, &uErr) {
+		t.Fatalf("Expand() error = %v, want *UnsetVariablesError", err)
+	}
+	want := []UnsetVariable{
+		{Name: "A", Line: 1},
+		{Name: "B", Line: 2},
+		{Name: "EMPTY", Message: "c is required", Line: 3},
+		{Name: "E", Message: "required", Line: 5},
+	}
+	if !reflect.DeepEqual(uErr.Variables, want) {
+		t.Errorf("Expand() unset = %+v, want %+v", uErr.Variables, want)
+	}
+	if err.Error() != "unset variables: A (line 1), B (line 2), EMPTY (line 3): c is required, E (line 5): required" {
+		t.Errorf("Expand() error = %q", err)
+	}
+
+	for in, msg := range map[string]string{
+		"a: ${A":      "line 1: unterminated ${ expression",
+		"\n${1A}":     "line 2: invalid variable name in ${1A}",
+		"${A:=x}":     "line 1: invalid operator in ${A:=x}",
+		"${}":         "line 1: invalid variable name in ${}",
+		"${A:-${B}\n": "line 1: unterminated ${ expression",
+	} {
+		if _, err = Expand(in, lookup); err == nil || err.Error() != msg {
+			t.Errorf("Expand(%q) error = %v, want %q", in, err, msg)
+		}
+	}
+}
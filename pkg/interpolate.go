@@ -0,0 +1,211 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// InterpolationError is returned by Interpolate when the value of a key cannot be resolved.
+type InterpolationError struct {
+	Path string // The key path of the value, e.g. "app.url".
+	Err  error
+}
+
+func (e *InterpolationError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *InterpolationError) Unwrap() error {
+	return e.Err
+}
+
+// Interpolate resolves the references in the string values of the struct pointed to by v:
+//
+//	${env:NAME}   the environment variable NAME, which must be set
+//	${file:PATH}  the content of the file PATH, without its trailing newline
+//	${app.name}   the value of another key, by the dotted path of Field.Path
+//	$${           a literal ${
+//
+// Strings of nested structs, slices, maps and interfaces are resolved, including the fields of
+// the structs they hold, e.g. servers[0].host; referenced keys may hold any scalar.
+// Reference cycles are reported as an *InterpolationError.
+func Interpolate(v interface{}, lookup LookupFunc) error {
+	in := interpolator{
+		lookup:  lookup,
+		entries: make(map[string]*entry),
+		state:   make(map[string]int),
+	}
+	err := Walk(v, func(f Field) error {
+		in.collect(f.Path, f.Value)
+		return nil
+	}, WalkElements())
+	if err != nil {
+		return err
+	}
+
+	for _, path := range in.order {
+		if _, err = in.resolve(path, nil); err != nil {
+			return err
+		}
+	}
+
+	// Map values and the values held by interfaces are visited as copies: the resolved
+	// strings are set by a second walk, which stores the copies back.
+	return Walk(v, func(f Field) error {
+		if e, ok := in.entries[f.Path]; ok && e.str {
+			if s := indirect(f.Value); s.Kind() == reflect.String && s.CanSet() {
+				s.SetString(e.value)
+			}
+		}
+		return nil
+	}, WalkElements())
+}
+
+// entry is a scalar value of the configuration.
+type entry struct {
+	value string
+	str   bool // Whether the value is a string, which may hold references.
+}
+
+const (
+	resolving = iota + 1
+	resolved
+)
+
+type interpolator struct {
+	lookup  LookupFunc
+	entries map[string]*entry
+	order   []string
+	state   map[string]int
+}
+
+// collect records the scalar held by v under path.
+func (in *interpolator) collect(path string, v reflect.Value) {
+	switch v = indirect(v); v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			in.add(path, &entry{value: v.String(), str: true})
+		}
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		in.add(path, &entry{value: fmt.Sprint(v.Interface())})
+	}
+}
+
+// indirect returns the value pointed to by v, following every level of pointers.
+// It returns the zero Value for nil pointers.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Ptr {
+		return reflect.Value{}
+	}
+
+	return v
+}
+
+func (in *interpolator) add(path string, e *entry) {
+	if _, ok := in.entries[path]; !ok {
+		in.order = append(in.order, path)
+	}
+	in.entries[path] = e
+}
+
+// resolve returns the interpolated value of path; chain holds the keys being resolved.
+func (in *interpolator) resolve(path string, chain []string) (string, error) {
+	e := in.entries[path]
+	if !e.str || in.state[path] == resolved {
+		return e.value, nil
+	}
+
+	chain = append(chain, path)
+	in.state[path] = resolving
+	value, err := in.expand(e.value, chain)
+	if err != nil {
+		return "", err
+	}
+	in.state[path] = resolved
+	e.value = value
+
+	return value, nil
+}
+
+// expand replaces the references of s, the value of the last key of chain.
+func (in *interpolator) expand(s string, chain []string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	path := chain[len(chain)-1]
+
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			b.WriteString("${")
+			i += 2
+			continue
+		case !strings.HasPrefix(s[i:], "${"):
+			b.WriteByte(s[i])
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", &InterpolationError{Path: path, Err: fmt.Errorf("unterminated reference in %q", s)}
+		}
+		value, err := in.reference(s[i+2:i+end], chain)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		i += end
+	}
+
+	return b.String(), nil
+}
+
+// reference returns the value of a single ${...} reference.
+func (in *interpolator) reference(ref string, chain []string) (string, error) {
+	path := chain[len(chain)-1]
+
+	switch {
+	case strings.HasPrefix(ref, "env:"):
+		name := strings.TrimPrefix(ref, "env:")
+		value, ok := in.lookup(name)
+		if !ok {
+			return "", &InterpolationError{Path: path, Err: fmt.Errorf("environment variable %s is not set", name)}
+		}
+		return value, nil
+	case strings.HasPrefix(ref, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, "file:"))
+		if err != nil {
+			return "", &InterpolationError{Path: path, Err: err}
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	if _, ok := in.entries[ref]; !ok {
+		return "", &InterpolationError{Path: path, Err: fmt.Errorf("unknown key %s", strconv.Quote(ref))}
+	}
+	if in.state[ref] == resolving {
+		cycle := append(append([]string(nil), chain[indexOf(chain, ref):]...), ref)
+		return "", &InterpolationError{Path: path, Err: fmt.Errorf("reference cycle %s", strings.Join(cycle, " -> "))}
+	}
+
+	return in.resolve(ref, chain)
+}
+
+func indexOf(s []string, v string) int {
+	for i := range s {
+		if s[i] == v {
+			return i
+		}
+	}
+
+	return -1
+}
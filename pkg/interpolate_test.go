@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInterpolate(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type server struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	}
+	type testStruct struct {
+		App struct {
+			Name string `yaml:"name"`
+			URL  string `yaml:"url"`
+		} `yaml:"app"`
+		Server   server            `yaml:"server"`
+		Timeout  time.Duration     `yaml:"timeout"`
+		Password *string           `yaml:"password"`
+		Home     string            `yaml:"home"`
+		Literal  string            `yaml:"literal"`
+		Modules  []string          `yaml:"modules"`
+		Labels   map[string]string `yaml:"labels"`
+	}
+
+	lookup := func(name string) (string, bool) {
+		if name == "HOME_DIR" {
+			return "/home/app", true
+		}
+		return "", false
+	}
+
+	password := "${file:" + secret + "}"
+	test := testStruct{
+		Server:   server{Host: "${app.name}.local", Port: 8080},
+		Timeout:  5 * time.Second,
+		Password: &password,
+		Home:     "${env:HOME_DIR}",
+		Literal:  "$${app.name}",
+		Modules:  []string{"${app.name}-core", "plain"},
+		Labels:   map[string]string{"service": "${app.name}", "timeout": "${timeout}"},
+	}
+	test.App.Name = "api"
+	test.App.URL = "http://${server.host}:${server.port}/"
+
+	if err := Interpolate(&test, lookup); err != nil {
+		t.Fatal(err)
+	}
+	if test.App.URL != "http://api.local:8080/" || test.Server.Host != "api.local" {
+		t.Errorf("Interpolate() = %+v", test)
+	}
+	if *test.Password != "s3cr3t" || test.Home != "/home/app" || test.Literal != "${app.name}" {
+		t.Errorf("Interpolate() password = %q, home = %q, literal = %q", *test.Password, test.Home, test.Literal)
+	}
+	if !reflect.DeepEqual(test.Modules, []string{"api-core", "plain"}) {
+		t.Errorf("Interpolate() modules = %v", test.Modules)
+	}
+	if !reflect.DeepEqual(test.Labels, map[string]string{"service": "api", "timeout": "5s"}) {
+		t.Errorf("Interpolate() labels = %v", test.Labels)
+	}
+}
+
+func TestInterpolate_Elements(t *testing.T) {
+	type server struct {
+		Host string `yaml:"host"`
+		URL  string `yaml:"url"`
+	}
+	type logConf struct {
+		Level string `yaml:"level"`
+	}
+	type testStruct struct {
+		Log     logConf                `yaml:",inline"`
+		Extra   map[string]interface{} `yaml:"extra"`
+		Domain  string                 `yaml:"domain"`
+		Servers []server               `yaml:"servers"`
+		ByName  map[string]server      `yaml:"by_name"`
+		Backups []*server              `yaml:"backups"`
+		Primary string                 `yaml:"primary"`
+		Nested  map[string][]server    `yaml:"nested"`
+	}
+	lookup := func(name string) (string, bool) {
+		if name == "PROBE_HOST" {
+			return "probe", true
+		}
+		return "", false
+	}
+
+	test := testStruct{
+		Log:     logConf{Level: "debug"},
+		Extra:   map[string]interface{}{"probe": "${env:PROBE_HOST}", "hosts": []interface{}{"${level}", 1}},
+		Domain:  "example.com",
+		Servers: []server{{Host: "${env:PROBE_HOST}", URL: "http://${servers[0].host}.${domain}"}},
+		ByName:  map[string]server{"a": {Host: "a.${domain}", URL: "http://${by_name.a.host}"}},
+		Backups: []*server{nil, {Host: "${servers[0].host}-backup"}},
+		Primary: "${by_name.a.host}",
+		Nested:  map[string][]server{"b": {{Host: "${domain}"}}},
+	}
+
+	if err := Interpolate(&test, lookup); err != nil {
+		t.Fatal(err)
+	}
+	if want := (server{Host: "probe", URL: "http://probe.example.com"}); test.Servers[0] != want {
+		t.Errorf("Interpolate() servers[0] = %+v, want %+v", test.Servers[0], want)
+	}
+	if want := (server{Host: "a.example.com", URL: "http://a.example.com"}); test.ByName["a"] != want {
+		t.Errorf("Interpolate() by_name.a = %+v, want %+v", test.ByName["a"], want)
+	}
+	if test.Backups[1].Host != "probe-backup" || test.Primary != "a.example.com" || test.Nested["b"][0].Host != "example.com" {
+		t.Errorf("Interpolate() = %+v", test)
+	}
+	want := map[string]interface{}{"probe": "probe", "hosts": []interface{}{"debug", 1}}
+	if !reflect.DeepEqual(test.Extra, want) {
+		t.Errorf("Interpolate() extra = %v, want %v", test.Extra, want)
+	}
+}
+
+func TestInterpolate_Errors(t *testing.T) {
+	type testStruct struct {
+		A string `yaml:"a"`
+		B string `yaml:"b"`
+		C string `yaml:"c"`
+	}
+	lookup := func(string) (string, bool) { return "", false }
+
+	tests := []struct {
+		name string
+		in   testStruct
+		want string
+	}{
+		{name: "cycle", in: testStruct{A: "${b}", B: "x${c}", C: "${a}"}, want: "c: reference cycle a -> b -> c -> a"},
+		{name: "self", in: testStruct{C: "${c}"}, want: "c: reference cycle c -> c"},
+		{name: "unknown key", in: testStruct{B: "${d}"}, want: `b: unknown key "d"`},
+		{name: "unset env", in: testStruct{A: "${env:MISSING}"}, want: "a: environment variable MISSING is not set"},
+		{name: "unterminated", in: testStruct{A: "${b"}, want: `a: unterminated reference in "${b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Interpolate(&tt.in, lookup)
+			var iErr *InterpolationError
+			if !errors.As(err, &iErr) || err.Error() != tt.want {
+				t.Errorf("Interpolate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	missing := testStruct{A: "${file:/does/not/exist}"}
+	if err := Interpolate(&missing, lookup); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Interpolate() error = %v, want fs.ErrNotExist", err)
+	}
+}
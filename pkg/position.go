@@ -0,0 +1,17 @@
+package pkg
+
+import "bytes"
+
+// Position returns the 1-based line and column of the byte offset in data,
+// or 0, 0 when offset is out of data.
+func Position(data []byte, offset int) (line, column int) {
+	if offset < 0 || offset > len(data) {
+		return 0, 0
+	}
+
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = offset - bytes.LastIndexByte(before, '\n')
+
+	return line, column
+}
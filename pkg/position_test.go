@@ -0,0 +1,26 @@
+package pkg
+
+import "testing"
+
+func TestPosition(t *testing.T) {
+	data := []byte("a: 1\nbb: 2\n")
+	tests := []struct {
+		offset       int
+		line, column int
+	}{
+		{0, 1, 1},
+		{3, 1, 4},
+		{5, 2, 1},
+		{9, 2, 5},
+		{len(data), 3, 1},
+		{-1, 0, 0},
+		{len(data) + 1, 0, 0},
+	}
+
+	for _, test := range tests {
+		line, column := Position(data, test.offset)
+		if line != test.line || column != test.column {
+			t.Errorf("Position(%d) = %d:%d, want %d:%d", test.offset, line, column, test.line, test.column)
+		}
+	}
+}
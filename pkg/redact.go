@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// RedactedMask replaces the strings of sensitive fields.
+const RedactedMask = "******"
+
+// IsSensitive reports whether a struct field is tagged sensitive:"true" or redact:"true".
+// A redact tag without a value, e.g. `redact:""`, also marks the field sensitive.
+func IsSensitive(field reflect.StructField) bool {
+	if s, ok := field.Tag.Lookup("sensitive"); ok {
+		b, err := strconv.ParseBool(s)
+		return err == nil && b
+	}
+	if s, ok := field.Tag.Lookup("redact"); ok {
+		b, err := strconv.ParseBool(s)
+		return s == "" || err == nil && b
+	}
+
+	return false
+}
+
+// Redact returns a copy of v, a struct or a pointer to a struct, whose sensitive fields
+// are masked: strings are replaced by RedactedMask, other values by their zero value.
+// Slices and maps keep their length with every element masked. v itself is left unchanged
+// and returned as is when it holds no sensitive field.
+func Redact(v interface{}) interface{} {
+	val := reflect.ValueOf(v)
+	isPtr := val.Kind() == reflect.Ptr
+	if isPtr {
+		if val.IsNil() {
+			return v
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct || !hasSensitive(val.Type(), make(map[reflect.Type]bool)) {
+		return v
+	}
+
+	cp := reflect.New(val.Type())
+	cp.Elem().Set(val)
+	redactValue(cp.Elem())
+	if isPtr {
+		return cp.Interface()
+	}
+
+	return cp.Elem().Interface()
+}
+
+// hasSensitive reports whether values of t may hold a sensitive field.
+func hasSensitive(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasSensitive(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.IsExported() && (IsSensitive(f) || hasSensitive(f.Type, seen)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// redactValue masks the sensitive fields held by v, a settable copy. The pointers, slices
+// and maps leading to sensitive fields are copied so the original value is not modified.
+func redactValue(v reflect.Value) {
+	if !hasSensitive(v.Type(), make(map[reflect.Type]bool)) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(v.Elem())
+		redactValue(cp.Elem())
+		v.Set(cp)
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		for i := 0; i < cp.Len(); i++ {
+			redactValue(cp.Index(i))
+		}
+		v.Set(cp)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			redactValue(v.Index(i))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			redactValue(elem)
+			cp.SetMapIndex(iter.Key(), elem)
+		}
+		v.Set(cp)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if IsSensitive(f) {
+				mask(v.Field(i))
+				continue
+			}
+			redactValue(v.Field(i))
+		}
+	}
+}
+
+// mask replaces a sensitive value, keeping the length of slices and maps.
+func mask(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(RedactedMask)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.New(v.Type().Elem())
+		mask(cp.Elem())
+		v.Set(cp)
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < cp.Len(); i++ {
+			mask(cp.Index(i))
+		}
+		v.Set(cp)
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			mask(elem)
+			cp.SetMapIndex(iter.Key(), elem)
+		}
+		v.Set(cp)
+	default:
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
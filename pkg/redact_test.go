@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	type credentials struct {
+		User     string
+		Password string `sensitive:"true"`
+	}
+	type testStruct struct {
+		Name     string
+		Token    *string           `redact:""`
+		Port     int               `sensitive:"true"`
+		Keys     []string          `redact:"true"`
+		Headers  map[string]string `sensitive:"true"`
+		Public   string            `sensitive:"false"`
+		DB       credentials
+		Replicas []credentials
+		Backup   *credentials
+	}
+
+	token := "abc"
+	test := testStruct{
+		Name:     "app",
+		Token:    &token,
+		Port:     5432,
+		Keys:     []string{"k1", "k2"},
+		Headers:  map[string]string{"X-Key": "xyz"},
+		Public:   "visible",
+		DB:       credentials{User: "admin", Password: "s3cr3t"},
+		Replicas: []credentials{{User: "r1", Password: "p1"}},
+		Backup:   &credentials{User: "b", Password: "p2"},
+	}
+	original := test
+	originalReplicas := append([]credentials(nil), test.Replicas...)
+
+	got, ok := Redact(&test).(*testStruct)
+	if !ok {
+		t.Fatalf("Redact() = %T, want *testStruct", Redact(&test))
+	}
+	want := testStruct{
+		Name:     "app",
+		Token:    got.Token,
+		Keys:     []string{RedactedMask, RedactedMask},
+		Headers:  map[string]string{"X-Key": RedactedMask},
+		Public:   "visible",
+		DB:       credentials{User: "admin", Password: RedactedMask},
+		Replicas: []credentials{{User: "r1", Password: RedactedMask}},
+		Backup:   &credentials{User: "b", Password: RedactedMask},
+	}
+	if !reflect.DeepEqual(*got, want) || *got.Token != RedactedMask {
+		t.Errorf("Redact() = %+v, want %+v", *got, want)
+	}
+
+	// The original value is left unchanged.
+	if !reflect.DeepEqual(test, original) || token != "abc" || test.Headers["X-Key"] != "xyz" ||
+		!reflect.DeepEqual(test.Replicas, originalReplicas) || test.Backup.Password != "p2" {
+		t.Errorf("Redact() modified the original: %+v", test)
+	}
+
+	if v, ok := Redact(test).(testStruct); !ok || v.DB.Password != RedactedMask {
+		t.Errorf("Redact() of a struct value = %+v", v)
+	}
+	plain := &struct{ Name string }{}
+	if Redact(plain) != interface{}(plain) || Redact("text") != "text" {
+		t.Errorf("Redact() must return values without sensitive fields as is")
+	}
+	if Redact((*credentials)(nil)) != interface{}((*credentials)(nil)) {
+		t.Errorf("Redact() of a nil pointer must return it as is")
+	}
+}
@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+)
+
+// KeyTags are the tags naming the document key of a struct field, by precedence.
+var KeyTags = []string{"yaml", "json", "toml"}
+
+// ParseTag returns the name and the options of the tag key of field,
+// e.g. "port" and ["omitempty"] for `yaml:"port,omitempty"`.
+func ParseTag(field reflect.StructField, key string) (string, []string) {
+	tag, ok := field.Tag.Lookup(key)
+	if !ok {
+		return "", nil
+	}
+
+	parts := strings.Split(tag, ",")
+
+	return parts[0], parts[1:]
+}
+
+// IsIgnored reports whether a struct field is left out of the documents: unexported fields
+// other than inline structs, and fields whose first tag of KeyTags with a name is "-".
+func IsIgnored(field reflect.StructField) bool {
+	if !field.IsExported() && !IsInline(field) {
+		return true
+	}
+
+	for _, key := range KeyTags {
+		if name, _ := ParseTag(field, key); name != "" {
+			return name == "-"
+		}
+	}
+
+	return false
+}
+
+// IsInline reports whether the fields of a struct field are flattened into its parent:
+// structs tagged yaml:",inline", mapstructure:",squash" or hcl:",squash", and embedded
+// structs without a key name in their KeyTags.
+func IsInline(field reflect.StructField) bool {
+	t := DerefType(field.Type)
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for _, key := range []string{"yaml", "mapstructure", "hcl"} {
+		if _, opts := ParseTag(field, key); hasOption(opts, "inline") || hasOption(opts, "squash") {
+			return true
+		}
+	}
+	if !field.Anonymous || IsTextUnmarshaler(t) {
+		return false
+	}
+	for _, key := range KeyTags {
+		if name, _ := ParseTag(field, key); name != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasOption reports whether option is one of the tag options opts.
+func hasOption(opts []string, option string) bool {
+	for _, o := range opts {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
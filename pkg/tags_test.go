@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTag(t *testing.T) {
+	field := reflect.StructField{Tag: `yaml:"port,omitempty,flow" json:",omitempty"`}
+
+	name, opts := ParseTag(field, "yaml")
+	if name != "port" || !reflect.DeepEqual(opts, []string{"omitempty", "flow"}) {
+		t.Errorf("ParseTag(yaml) = %q, %v", name, opts)
+	}
+	if name, opts = ParseTag(field, "json"); name != "" || !reflect.DeepEqual(opts, []string{"omitempty"}) {
+		t.Errorf("ParseTag(json) = %q, %v", name, opts)
+	}
+	if name, opts = ParseTag(field, "toml"); name != "" || opts != nil {
+		t.Errorf("ParseTag(toml) = %q, %v", name, opts)
+	}
+}
+
+func TestIsInline(t *testing.T) {
+	type Base struct {
+		Debug bool
+	}
+	type base struct {
+		Trace bool
+	}
+	type testStruct struct {
+		Base
+		base
+		*time.Location
+		Named   Base  `yaml:"named"`
+		Inline  Base  `yaml:",inline"`
+		Squash  *Base `mapstructure:",squash"`
+		Skipped Base  `yaml:"-"`
+		Time    time.Time
+		Plain   string `yaml:",inline"`
+		hidden  string
+	}
+
+	typ := reflect.TypeOf(testStruct{})
+	inline := map[string]bool{"Base": true, "base": true, "Location": true, "Inline": true, "Squash": true}
+	ignored := map[string]bool{"Skipped": true, "hidden": true}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if got := IsInline(field); got != inline[field.Name] {
+			t.Errorf("IsInline(%s) = %v, want %v", field.Name, got, inline[field.Name])
+		}
+		if got := IsIgnored(field); got != ignored[field.Name] {
+			t.Errorf("IsIgnored(%s) = %v, want %v", field.Name, got, ignored[field.Name])
+		}
+	}
+}
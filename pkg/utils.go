@@ -4,48 +4,179 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
-func GeneratePlaceholderMap(v interface{}, keys map[string]interface{}, prefix string) map[string]interface{} {
-	result := make(map[string]interface{})
-	val := reflect.ValueOf(v).Elem()
-	typ := val.Type()
+// GeneratePlaceholderMap returns the env template of v, a struct or a pointer to a struct:
+// a map of its keys to ${NAME} placeholders, NAME being the prefix followed by the FieldEnvName
+// of every field joined by "_". The value of every placeholder is stored in keys under NAME.
+// Nil pointers to structs are walked as zero values, the elements of non-empty slices of structs
+// are indexed, e.g. SERVERS_0_HOST, and the entries of non-empty string-keyed maps are named
+// after their key. Empty slices and maps are a single placeholder. The values stored in keys
+// are dereferenced: a nil pointer is stored as the zero value of its element type.
+func GeneratePlaceholderMap(v interface{}, keys map[string]interface{}, prefix string) (map[string]interface{}, error) {
+	return placeholders{keys: keys}.generate(v, prefix)
+}
+
+// SensitivePlaceholders returns the names of the placeholders of GeneratePlaceholderMap with
+// the same prefix which hold a sensitive field or a value nested in one, see IsSensitive.
+func SensitivePlaceholders(v interface{}, prefix string) (map[string]bool, error) {
+	p := placeholders{keys: make(map[string]interface{}), sensitive: make(map[string]bool)}
+	if _, err := p.generate(v, prefix); err != nil {
+		return nil, err
+	}
+
+	return p.sensitive, nil
+}
+
+// placeholders collects the values of the placeholders of GeneratePlaceholderMap.
+type placeholders struct {
+	keys      map[string]interface{}
+	sensitive map[string]bool // The names of the sensitive values, collected when not nil.
+}
+
+func (p placeholders) generate(v interface{}, prefix string) (map[string]interface{}, error) {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			val = reflect.New(val.Type().Elem())
+		}
+		val = val.Elem()
+	}
 
-	if val.Kind() == reflect.String {
-		name := fmt.Sprintf("%s%s", prefix, strings.ToUpper(ToSnakeCase(typ.Name())))
-		envPlaceholder := fmt.Sprintf("${%s}", name)
-		keys[name] = val.Interface()
-		result[typ.Name()] = envPlaceholder
+	switch val.Kind() {
+	case reflect.Struct:
+		return p.placeholderStruct(val, prefix, false)
+	case reflect.Invalid:
+		return nil, fmt.Errorf("cannot generate placeholders of %v", v)
+	}
 
-		return result
+	result := make(map[string]interface{})
+	name := prefix + EnvName(val.Type().Name())
+	placeholder, err := p.placeholderValue(val, name, false)
+	if err != nil {
+		return nil, err
 	}
+	result[val.Type().Name()] = placeholder
+
+	return result, nil
+}
 
+// placeholderStruct returns the placeholders of the fields of val; sensitive tells whether
+// val is held by a sensitive field.
+func (p placeholders) placeholderStruct(val reflect.Value, prefix string, sensitive bool) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	typ := val.Type()
 	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
 		fieldType := typ.Field(i)
-		fieldName := fieldType.Tag.Get("yaml")
-		if fieldName == "" {
-			fieldName = strings.ToLower(fieldType.Name)
-		}
-		switch field.Kind() {
-		case reflect.Struct:
-			nestedMap := GeneratePlaceholderMap(field.Addr().Interface(), keys, prefix+strings.ToUpper(fieldType.Name)+"_")
-			result[fieldName] = nestedMap
-		// case reflect.Slice:
-		// 	for j := 0; j < field.Len(); j++ {
-		// 		nestedMap := GeneratePlaceholderMap(field.Index(j).Addr().Interface(), keys, prefix+strings.ToUpper(fieldType.Name)+"_"+strconv.Itoa(j)+"_")
-		// 		result[fieldName] = nestedMap
-		// 	}
-		default:
-			name := fmt.Sprintf("%s%s", prefix, strings.ToUpper(ToSnakeCase(fieldType.Name)))
-			envPlaceholder := fmt.Sprintf("${%s}", name)
-			keys[name] = field.Interface()
-			result[fieldName] = envPlaceholder
+		envName := FieldEnvName(fieldType)
+		if envName == "-" {
+			continue
+		}
+
+		if IsInline(fieldType) {
+			field := val.Field(i)
+			for field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					field = reflect.New(field.Type().Elem())
+				}
+				field = field.Elem()
+			}
+			namePrefix := prefix
+			if envName != "" {
+				namePrefix += envName + "_"
+			}
+			inline, err := p.placeholderStruct(field, namePrefix, sensitive || IsSensitive(fieldType))
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range inline {
+				result[k] = v
+			}
+			continue
+		}
+
+		placeholder, err := p.placeholderValue(val.Field(i), prefix+envName, sensitive || IsSensitive(fieldType))
+		if err != nil {
+			return nil, err
+		}
+		result[FieldKey(fieldType)] = placeholder
+	}
+
+	return result, nil
+}
+
+// placeholderValue returns the placeholder of v named name, or the nested placeholders
+// of structs, slices of structs and maps.
+func (p placeholders) placeholderValue(v reflect.Value, name string, sensitive bool) (interface{}, error) {
+	elem := v
+	for elem.Kind() == reflect.Ptr {
+		if elem.IsNil() {
+			elem = reflect.New(elem.Type().Elem())
+		}
+		elem = elem.Elem()
+	}
+
+	switch elem.Kind() {
+	case reflect.Struct:
+		if !IsTextUnmarshaler(elem.Type()) {
+			return p.placeholderStruct(elem, name+"_", sensitive)
+		}
+	case reflect.Slice, reflect.Array:
+		if elem.Len() > 0 && DerefType(elem.Type().Elem()).Kind() == reflect.Struct {
+			items := make([]interface{}, 0, elem.Len())
+			for j := 0; j < elem.Len(); j++ {
+				item, err := p.placeholderValue(elem.Index(j), name+"_"+strconv.Itoa(j), sensitive)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			return items, nil
+		}
+	case reflect.Map:
+		if elem.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%s: unsupported map key kind %s", name, elem.Type().Key().Kind())
 		}
+		if elem.Len() > 0 {
+			items := make(map[string]interface{}, elem.Len())
+			iter := elem.MapRange()
+			for iter.Next() {
+				item, err := p.placeholderValue(iter.Value(), name+"_"+envKey(iter.Key().String()), sensitive)
+				if err != nil {
+					return nil, err
+				}
+				items[iter.Key().String()] = item
+			}
+			return items, nil
+		}
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return nil, fmt.Errorf("%s: unsupported kind %s", name, elem.Kind())
+	}
+
+	// Nil pointers are stored as the zero value they are walked as.
+	p.keys[name] = elem.Interface()
+	if sensitive && p.sensitive != nil {
+		p.sensitive[name] = true
 	}
 
-	return result
+	return fmt.Sprintf("${%s}", name), nil
+}
+
+// envKey returns the variable name part of a map key: its upper case letters and digits,
+// any other character being replaced by an underscore.
+func envKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
 }
 
 // https://stackoverflow.com/questions/56616196/how-to-convert-camel-case-string-to-snake-case
@@ -1,7 +1,7 @@
 package pkg
 
 import (
-	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -46,10 +46,18 @@ func TestGeneratePlaceholderMap(t *testing.T) {
 		URL string      `yaml:"url"`
 		B   interface{} `yaml:"b"`
 	}
+	type testDB struct {
+		Name string `yaml:"name"`
+	}
 	type testStruct struct {
-		Host  string `yaml:"host"`
-		Port  int    `yaml:"port"`
-		Inner []testInnerStruct
+		Host   string `yaml:"host"`
+		Port   int    `yaml:"port"`
+		Inner  []testInnerStruct
+		DB     *testDB           `yaml:"db"`
+		Labels map[string]string `yaml:"labels"`
+		Peers  map[string]testDB `yaml:"peers"`
+		Tags   []string          `yaml:"tags"`
+		Empty  []testDB          `yaml:"empty"`
 	}
 	keys := make(map[string]interface{})
 	prefix := "APP_"
@@ -60,19 +68,129 @@ func TestGeneratePlaceholderMap(t *testing.T) {
 			{URL: "http://localhost:8080"},
 			{URL: "http://localhost:8081", B: 1},
 		},
+		Labels: map[string]string{"team-name": "core"},
+		Peers:  map[string]testDB{"eu": {Name: "db-eu"}},
+		Tags:   []string{"a"},
 	}
 
-	got := GeneratePlaceholderMap(&test, keys, prefix)
-	fmt.Println(got)
+	got, err := GeneratePlaceholderMap(&test, keys, prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
 	want := map[string]interface{}{
 		"host": "${APP_HOST}",
 		"port": "${APP_PORT}",
-		"inner": []map[string]interface{}{
-			{"url": "${APP_INNER_0_URL}", "b": "${APP_INNER_0_B}"},
-			{"url": "${APP_INNER_1_URL}", "b": "${APP_INNER_1_B}"},
+		"inner": []interface{}{
+			map[string]interface{}{"url": "${APP_INNER_0_URL}", "b": "${APP_INNER_0_B}"},
+			map[string]interface{}{"url": "${APP_INNER_1_URL}", "b": "${APP_INNER_1_B}"},
 		},
+		"db":     map[string]interface{}{"name": "${APP_DB_NAME}"},
+		"labels": map[string]interface{}{"team-name": "${APP_LABELS_TEAM_NAME}"},
+		"peers":  map[string]interface{}{"eu": map[string]interface{}{"name": "${APP_PEERS_EU_NAME}"}},
+		"tags":   "${APP_TAGS}",
+		"empty":  "${APP_EMPTY}",
 	}
-	if len(got) != len(want) {
+	if !reflect.DeepEqual(got, want) {
 		t.Errorf("GeneratePlaceholderMap() = %v, want %v", got, want)
 	}
+	if keys["APP_INNER_1_URL"] != "http://localhost:8081" || keys["APP_LABELS_TEAM_NAME"] != "core" ||
+		keys["APP_PEERS_EU_NAME"] != "db-eu" || keys["APP_DB_NAME"] != "" {
+		t.Errorf("GeneratePlaceholderMap() keys = %v", keys)
+	}
+	if test.DB != nil {
+		t.Error("GeneratePlaceholderMap() must not modify v")
+	}
+
+	// Pointers are stored dereferenced, nil pointers as their zero value.
+	name := "db"
+	keys = make(map[string]interface{})
+	if _, err = GeneratePlaceholderMap(&struct {
+		Name *string `yaml:"name"`
+		Dir  *string `yaml:"dir"`
+		Port *int    `yaml:"port"`
+	}{Name: &name}, keys, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, map[string]interface{}{"NAME": "db", "DIR": "", "PORT": 0}) {
+		t.Errorf("GeneratePlaceholderMap() keys = %v", keys)
+	}
+
+	// Structs are accepted by value.
+	if _, err = GeneratePlaceholderMap(test, make(map[string]interface{}), ""); err != nil {
+		t.Errorf("GeneratePlaceholderMap() error = %v", err)
+	}
+
+	for _, v := range []interface{}{
+		nil,
+		&struct{ C chan int }{},
+		&struct{ F func() }{},
+		&struct{ M map[int]string }{},
+	} {
+		if _, err = GeneratePlaceholderMap(v, make(map[string]interface{}), ""); err == nil {
+			t.Errorf("GeneratePlaceholderMap(%T) expected an error", v)
+		}
+	}
+}
+
+func TestGeneratePlaceholderMap_Tags(t *testing.T) {
+	type Base struct {
+		Debug bool `yaml:"debug"`
+	}
+	type Log struct {
+		Level string `toml:"level"`
+	}
+	type testStruct struct {
+		Base
+		Log      `yaml:",inline"`
+		DB       Base   `env:"DATABASE" json:"db"`
+		Host     string `yaml:"host,omitempty"`
+		FilesDir string `json:"filesDir"`
+		Token    string `env:"API_TOKEN"`
+		Skipped  string `yaml:"-"`
+		Ignored  string `env:"-"`
+		internal string
+	}
+
+	keys := make(map[string]interface{})
+	got, err := GeneratePlaceholderMap(&testStruct{internal: "x"}, keys, "APP_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"debug":    "${APP_DEBUG}",
+		"level":    "${APP_LEVEL}",
+		"db":       map[string]interface{}{"debug": "${APP_DATABASE_DEBUG}"},
+		"host":     "${APP_HOST}",
+		"filesDir": "${APP_FILES_DIR}",
+		"token":    "${APP_API_TOKEN}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GeneratePlaceholderMap() = %v, want %v", got, want)
+	}
+	if len(keys) != len(want) {
+		t.Errorf("GeneratePlaceholderMap() keys = %v", keys)
+	}
+}
+
+func TestSensitivePlaceholders(t *testing.T) {
+	type db struct {
+		User     string `yaml:"user"`
+		Password string `yaml:"password" sensitive:"true"`
+	}
+	type testStruct struct {
+		DB      db             `yaml:"db"`
+		Replica *db            `yaml:"replica"`
+		Keys    map[string]int `yaml:"keys" redact:""`
+		Token   string         `env:"API_TOKEN" sensitive:"true"`
+		Region  string         `yaml:"region"`
+	}
+
+	got, err := SensitivePlaceholders(&testStruct{Keys: map[string]int{"a": 1}}, "APP_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"APP_DB_PASSWORD": true, "APP_REPLICA_PASSWORD": true, "APP_KEYS_A": true, "APP_API_TOKEN": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SensitivePlaceholders() = %v, want %v", got, want)
+	}
 }
@@ -0,0 +1,220 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrSkipStruct is returned by a WalkFunc to skip the fields and elements of the visited value.
+var ErrSkipStruct = errors.New("skip struct")
+
+// Field describes a struct field, or an element with WalkElements, visited by Walk.
+type Field struct {
+	Path   string              // The dotted key path, e.g. "app.port" or "servers[0].host".
+	Names  []string            // The Go field names from the root, e.g. ["App", "Port"].
+	Struct reflect.StructField // The field definition, zero for elements.
+	Value  reflect.Value       // The settable value.
+}
+
+// WalkFunc is called by Walk for every visited field.
+type WalkFunc func(f Field) error
+
+// WalkOption configures Walk.
+type WalkOption func(w *walker)
+
+// WalkElements makes Walk visit the items of slices and arrays, the values of maps and
+// the values held by interfaces too, e.g. "servers[0]" and "labels.team", followed by
+// their fields. Map values and the values held by interfaces are not addressable: a copy
+// is visited, then stored back.
+func WalkElements() WalkOption {
+	return func(w *walker) {
+		w.elements = true
+	}
+}
+
+// Walk calls fn for every exported field of the struct pointed to by v, depth first.
+// Nested structs are visited before their fields, and the fields of inline structs, see
+// IsInline, are keyed like those of their parent. A nil pointer to a struct is only
+// descended into when fn allocates it.
+func Walk(v interface{}, fn WalkFunc, opts ...WalkOption) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return errors.New("walk: expected a non-nil pointer to a struct")
+	}
+
+	w := walker{fn: fn}
+	for _, opt := range opts {
+		opt(&w)
+	}
+
+	return w.walk(val.Elem(), "", nil)
+}
+
+type walker struct {
+	fn       WalkFunc
+	elements bool
+}
+
+func (w *walker) walk(val reflect.Value, path string, names []string) error {
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		fieldType := typ.Field(i)
+		inline := IsInline(fieldType)
+		if !fieldType.IsExported() && !inline {
+			continue
+		}
+
+		f := Field{
+			Path:   path,
+			Names:  append(names[:len(names):len(names)], fieldType.Name),
+			Struct: fieldType,
+			Value:  val.Field(i),
+		}
+		if !inline {
+			f.Path = JoinPath(path, FieldKey(fieldType))
+		}
+		if !fieldType.IsExported() {
+			// Only the promoted fields of an embedded unexported struct can be set.
+			if nested, ok := structValue(f.Value); ok {
+				if err := w.walk(nested, f.Path, f.Names); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := w.visit(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// visit calls fn for f, then walks the fields or the elements of its value.
+func (w *walker) visit(f Field) error {
+	err := w.fn(f)
+	if errors.Is(err, ErrSkipStruct) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if nested, ok := structValue(f.Value); ok {
+		return w.walk(nested, f.Path, f.Names)
+	}
+	if w.elements {
+		return w.visitElements(f)
+	}
+
+	return nil
+}
+
+// visitElements visits the elements held by the value of f.
+func (w *walker) visitElements(f Field) error {
+	v := f.Value
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	elem := Field{Path: f.Path, Names: f.Names}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return nil
+		}
+		elem.Value = copyValue(v.Elem())
+		if err := w.visit(elem); err != nil {
+			return err
+		}
+		v.Set(elem.Value)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			elem.Path, elem.Value = fmt.Sprintf("%s[%d]", f.Path, i), v.Index(i)
+			if err := w.visit(elem); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem.Path = JoinPath(f.Path, fmt.Sprint(iter.Key().Interface()))
+			elem.Value = copyValue(iter.Value())
+			if err := w.visit(elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem.Value)
+		}
+	}
+
+	return nil
+}
+
+// copyValue returns a settable copy of v.
+func copyValue(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+
+	return c
+}
+
+// structValue returns the struct held by v, following a non-nil pointer.
+// Structs decoding themselves from text, such as time.Time, are treated as scalars.
+func structValue(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return v, false
+	}
+	if IsTextUnmarshaler(v.Type()) {
+		return v, false
+	}
+
+	return v, true
+}
+
+// FieldKey returns the configuration key of a struct field: the name of its yaml, json
+// or toml tag, in that order, or the lowercased field name.
+func FieldKey(field reflect.StructField) string {
+	for _, tag := range KeyTags {
+		if name, _ := ParseTag(field, tag); name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return strings.ToLower(field.Name)
+}
+
+// JoinPath appends key to the dotted key path path.
+func JoinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// DerefType returns the type pointed to by t, following every level of pointers.
+func DerefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// IsTextUnmarshaler reports whether the values of t decode themselves from text,
+// i.e. whether *t implements encoding.TextUnmarshaler.
+func IsTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWalk(t *testing.T) {
+	type inner struct {
+		URL string `json:"url"`
+	}
+	type testStruct struct {
+		Host     string `yaml:"host,omitempty"`
+		Port     int    `toml:"port"`
+		internal string
+		Ignored  string `yaml:"-"`
+		Started  time.Time
+		Inner    inner `yaml:"inner"`
+		Nil      *inner
+		Alloc    *inner `yaml:"alloc"`
+		Skipped  inner  `yaml:"skipped"`
+	}
+
+	test := testStruct{internal: "x"}
+	var paths []string
+	err := Walk(&test, func(f Field) error {
+		paths = append(paths, f.Path)
+		if f.Path == "alloc" {
+			f.Value.Set(reflect.New(f.Value.Type().Elem()))
+		}
+		if f.Path == "skipped" {
+			return ErrSkipStruct
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"host", "port", "ignored", "started", "inner", "inner.url", "nil", "alloc", "alloc.url", "skipped"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Walk() paths = %v, want %v", paths, want)
+	}
+	if test.Alloc == nil {
+		t.Error("Walk() did not keep the allocated pointer")
+	}
+
+	if err = Walk(test, func(Field) error { return nil }); err == nil {
+		t.Error("Walk() expected an error for a non-pointer")
+	}
+}
+
+func TestFieldKey(t *testing.T) {
+	type testStruct struct {
+		A string `yaml:"a_yaml" json:"a_json"`
+		B string `json:"b_json,omitempty" toml:"b_toml"`
+		C string `toml:"c_toml"`
+		D string `yaml:",omitempty"`
+		E string
+	}
+
+	typ := reflect.TypeOf(testStruct{})
+	want := []string{"a_yaml", "b_json", "c_toml", "d", "e"}
+	for i, w := range want {
+		if got := FieldKey(typ.Field(i)); got != w {
+			t.Errorf("FieldKey(%s) = %v, want %v", typ.Field(i).Name, got, w)
+		}
+	}
+}
+
+func TestWalk_Elements(t *testing.T) {
+	type server struct {
+		Host string `yaml:"host"`
+	}
+	type logConf struct {
+		Level string `yaml:"level"`
+	}
+	type testStruct struct {
+		Log     logConf                `yaml:",inline"`
+		Servers []server               `yaml:"servers"`
+		ByName  map[string]server      `yaml:"by_name"`
+		Extra   map[string]interface{} `yaml:"extra"`
+	}
+
+	test := testStruct{
+		Servers: []server{{Host: "a"}},
+		ByName:  map[string]server{"b": {Host: "b"}},
+		Extra:   map[string]interface{}{"list": []interface{}{"c"}},
+	}
+	var paths []string
+	err := Walk(&test, func(f Field) error {
+		paths = append(paths, f.Path+":"+f.Value.Kind().String())
+		if f.Value.Kind() == reflect.String {
+			f.Value.SetString(f.Value.String() + "!")
+		}
+		return nil
+	}, WalkElements())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		":struct", "level:string",
+		"servers:slice", "servers[0]:struct", "servers[0].host:string",
+		"by_name:map", "by_name.b:struct", "by_name.b.host:string",
+		"extra:map", "extra.list:interface", "extra.list:slice", "extra.list[0]:interface", "extra.list[0]:string",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Walk() paths = %v, want %v", paths, want)
+	}
+	if test.Servers[0].Host != "a!" || test.ByName["b"].Host != "b!" || test.Extra["list"].([]interface{})[0] != "c!" {
+		t.Errorf("Walk() did not store the elements back: %+v", test)
+	}
+}
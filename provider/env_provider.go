@@ -6,33 +6,264 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/rottendev/config/pkg"
 	"gopkg.in/yaml.v3"
 )
 
+// EnvLayer is a set of variables consulted by EnvProvider.
+type EnvLayer int
+
+const (
+	EnvFileLayer    EnvLayer = iota // The variables of the .env Filename.
+	ProcessEnvLayer                 // The process environment.
+	VarsLayer                       // The Vars of the provider.
+)
+
+// DefaultEnvLayers is the lookup order used when EnvProvider.Layers is empty.
+var DefaultEnvLayers = []EnvLayer{EnvFileLayer, ProcessEnvLayer, VarsLayer}
+
+// EnvProvider expands a YAML template with the variables of its layers.
+// Without a template, it populates the struct from the variables alone, see pkg.DecodeEnv.
+//
+// The .env Filename is parsed into an isolated lookup: the process environment is left
+// untouched unless Export is set.
 type EnvProvider struct {
 	Filename string
-	prefix   string
+	Prefix   string            // The prefix of the variable names, e.g. APP_.
+	Vars     map[string]string // Caller-supplied variables.
+	Layers   []EnvLayer        // The layers in precedence order, the last one setting a variable wins.
+	Export   bool              // Write the .env variables missing from the process environment to it.
 }
 
 func (e EnvProvider) Decode(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return e.decodeEnv(v)
+	}
+
+	configData, err := e.template(data, v)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(configData, v)
+}
+
+// DecodeStrict decodes data like Decode but rejects template keys without a matching struct field.
+func (e EnvProvider) DecodeStrict(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return e.decodeEnv(v)
+	}
+
+	configData, err := e.template(data, v)
+	if err != nil {
+		return err
+	}
+
+	return decodeYamlStrict(configData, v)
+}
+
+// template expands data and renames its keys to the ones decoded by yaml.v3, see yamlKeys.
+func (e EnvProvider) template(data []byte, v interface{}) ([]byte, error) {
+	configData, err := e.Expand(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if yaml.Unmarshal(configData, &node) != nil || !yamlKeys(&node, reflect.TypeOf(v)) {
+		// Syntax errors are reported by the decoder.
+		return configData, nil
+	}
+
+	return yaml.Marshal(&node)
+}
+
+// yamlKeys adapts node, a template of a value of type t keyed like pkg.GeneratePlaceholderMap,
+// to yaml.v3: the keys named after json or toml tags are renamed, and the keys of inline
+// structs yaml.v3 does not flatten, such as untagged embedded structs, are nested under
+// the key of the struct. It reports whether node was changed.
+func yamlKeys(node *yaml.Node, t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	t = pkg.DerefType(t)
+	changed := false
+	switch {
+	case node.Kind == yaml.DocumentNode:
+		for _, n := range node.Content {
+			changed = yamlKeys(n, t) || changed
+		}
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, n := range node.Content {
+			changed = yamlKeys(n, t.Elem()) || changed
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 1; i < len(node.Content); i += 2 {
+			changed = yamlKeys(node.Content[i], t.Elem()) || changed
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if pkg.IsIgnored(field) {
+				continue
+			}
+
+			name, opts := pkg.ParseTag(field, "yaml")
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			if pkg.IsInline(field) {
+				if slices.Contains(opts, "inline") {
+					changed = yamlKeys(node, field.Type) || changed
+					continue
+				}
+				if nested := nestYamlKeys(node, templateKeys(field.Type)); nested != nil {
+					yamlKeys(nested, field.Type)
+					node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, nested)
+					changed = true
+				}
+				continue
+			}
+
+			key := pkg.FieldKey(field)
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if node.Content[j].Value != key {
+					continue
+				}
+				if key != name {
+					node.Content[j].Value = name
+					changed = true
+				}
+				changed = yamlKeys(node.Content[j+1], field.Type) || changed
+			}
+		}
+	}
+
+	return changed
+}
+
+// nestYamlKeys moves the entries of the mapping node whose key is in keys to a new mapping node.
+func nestYamlKeys(node *yaml.Node, keys map[string]bool) *yaml.Node {
+	var (
+		nested  *yaml.Node
+		content = make([]*yaml.Node, 0, len(node.Content))
+	)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !keys[node.Content[i].Value] {
+			content = append(content, node.Content[i], node.Content[i+1])
+			continue
+		}
+		if nested == nil {
+			nested = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		nested.Content = append(nested.Content, node.Content[i], node.Content[i+1])
+	}
+	node.Content = content
+
+	return nested
+}
+
+// templateKeys returns the keys of the fields of t in a template, including inline structs.
+func templateKeys(t reflect.Type) map[string]bool {
+	t = pkg.DerefType(t)
+	keys := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case pkg.IsIgnored(field):
+		case pkg.IsInline(field):
+			for k := range templateKeys(field.Type) {
+				keys[k] = true
+			}
+		default:
+			keys[pkg.FieldKey(field)] = true
+		}
+	}
+
+	return keys
+}
+
+// Expand performs variable substitution on the YAML template data, see pkg.Expand.
+func (e EnvProvider) Expand(data []byte) ([]byte, error) {
+	lookup, err := e.lookup()
+	if err != nil {
+		return nil, err
+	}
+
+	expanded, err := pkg.Expand(string(data), lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(expanded), nil
+}
+
+// decodeEnv populates v from the variables named after its fields.
+func (e EnvProvider) decodeEnv(v interface{}) error {
+	lookup, err := e.lookup()
+	if err != nil {
+		return err
+	}
+
+	return pkg.DecodeEnv(v, e.Prefix, lookup)
+}
+
+// lookup reads the .env file and returns the lookup of the variables through the layers.
+func (e EnvProvider) lookup() (pkg.LookupFunc, error) {
+	fileVars := map[string]string{}
 	if e.Filename != "" {
-		if err := godotenv.Load(e.Filename); err != nil {
-			return err
+		var err error
+		if fileVars, err = godotenv.Read(e.Filename); err != nil {
+			return nil, err
 		}
 	}
-	// Perform variable substitution on the YAML template
-	configData := os.ExpandEnv(string(data))
 
-	return yaml.Unmarshal([]byte(configData), v)
+	if e.Export {
+		for name, value := range fileVars {
+			if _, ok := os.LookupEnv(name); !ok {
+				if err := os.Setenv(name, value); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	layers := e.Layers
+	if len(layers) == 0 {
+		layers = DefaultEnvLayers
+	}
+
+	return func(name string) (string, bool) {
+		for i := len(layers) - 1; i >= 0; i-- {
+			var value string
+			var ok bool
+			switch layers[i] {
+			case EnvFileLayer:
+				value, ok = fileVars[name]
+			case ProcessEnvLayer:
+				value, ok = os.LookupEnv(name)
+			case VarsLayer:
+				value, ok = e.Vars[name]
+			}
+			if ok {
+				return value, true
+			}
+		}
+
+		return "", false
+	}, nil
 }
 
 func (e EnvProvider) Encode(v any) ([]byte, error) {
 	keys := make(map[string]interface{})
-	pkg.GeneratePlaceholderMap(v, keys, e.prefix)
+	if _, err := pkg.GeneratePlaceholderMap(pkg.Redact(v), keys, e.Prefix); err != nil {
+		return nil, err
+	}
 
 	// sort keys
 	sortedKV := make([]string, 0, len(keys))
@@ -44,9 +275,9 @@ func (e EnvProvider) Encode(v any) ([]byte, error) {
 	b := bytes.Buffer{}
 	for _, k := range sortedKV {
 		val := keys[k]
-		// if val is pointer, get the value
-		if reflect.ValueOf(val).Kind() == reflect.Ptr {
-			val = reflect.ValueOf(val).Elem().Interface()
+		if val == nil {
+			// Nil interfaces have no value.
+			val = ""
 		}
 
 		if reflect.ValueOf(val).Kind() == reflect.Slice {
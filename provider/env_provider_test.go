@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type envTest struct {
+	App struct {
+		Name string `yaml:"name"`
+		Port int    `yaml:"port"`
+	} `yaml:"app"`
+	Region string `yaml:"region"`
+}
+
+const envTemplate = "app:\n  name: ${APP_NAME}\n  port: ${APP_PORT}\nregion: ${REGION}\n"
+
+func TestEnvProvider_Decode(t *testing.T) {
+	t.Run("Isolated file", func(t *testing.T) {
+		t.Setenv("REGION", "process")
+
+		p := EnvProvider{Filename: "../testdata/config.test.env"}
+		data := envTest{}
+		require.NoError(t, p.Decode([]byte(envTemplate), &data))
+		require.Equal(t, "appEnv", data.App.Name)
+		require.Equal(t, 8085, data.App.Port)
+		require.Equal(t, "process", data.Region)
+
+		_, ok := os.LookupEnv("APP_NAME")
+		require.False(t, ok)
+	})
+
+	t.Run("Vars", func(t *testing.T) {
+		t.Setenv("APP_NAME", "process")
+
+		p := EnvProvider{
+			Filename: "../testdata/config.test.env",
+			Vars:     map[string]string{"APP_NAME": "vars", "REGION": "vars"},
+		}
+		data := envTest{}
+		require.NoError(t, p.Decode([]byte(envTemplate), &data))
+		require.Equal(t, "vars", data.App.Name)
+		require.Equal(t, "vars", data.Region)
+		require.Equal(t, 8085, data.App.Port)
+	})
+
+	t.Run("Layers", func(t *testing.T) {
+		t.Setenv("APP_NAME", "process")
+
+		p := EnvProvider{
+			Filename: "../testdata/config.test.env",
+			Vars:     map[string]string{"APP_NAME": "vars"},
+			Layers:   []EnvLayer{VarsLayer, ProcessEnvLayer, EnvFileLayer},
+		}
+		data := envTest{}
+		require.NoError(t, p.Decode([]byte(envTemplate), &data))
+		require.Equal(t, "appEnv", data.App.Name)
+
+		p.Layers = []EnvLayer{VarsLayer}
+		data = envTest{}
+		require.NoError(t, p.Decode([]byte("app:\n  name: ${APP_NAME}\n  port: ${APP_PORT:-0}\n"), &data))
+		require.Equal(t, "vars", data.App.Name)
+		require.Equal(t, 0, data.App.Port)
+	})
+
+	t.Run("Env only", func(t *testing.T) {
+		p := EnvProvider{Vars: map[string]string{"APP_APP_PORT": "9090", "APP_REGION": "vars"}, Prefix: "APP_"}
+		data := envTest{}
+		require.NoError(t, p.Decode(nil, &data))
+		require.Equal(t, 9090, data.App.Port)
+		require.Equal(t, "vars", data.Region)
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		// t.Setenv restores the variables exported by the provider.
+		for _, name := range []string{"APP_PORT", "FILES_DIR", "MODULES", "REGION"} {
+			t.Setenv(name, "")
+			require.NoError(t, os.Unsetenv(name))
+		}
+		t.Setenv("APP_NAME", "process")
+
+		p := EnvProvider{Filename: "../testdata/config.test.env", Export: true}
+		require.NoError(t, p.Decode([]byte(envTemplate), &envTest{}))
+		require.Equal(t, "process", os.Getenv("APP_NAME"))
+		require.Equal(t, "8085", os.Getenv("APP_PORT"))
+		require.Equal(t, "us-west-4", os.Getenv("REGION"))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		p := EnvProvider{Filename: "../testdata/missing.env"}
+		require.Error(t, p.Decode([]byte(envTemplate), &envTest{}))
+	})
+}
+
+func TestEnvProvider_Encode(t *testing.T) {
+	port := 8080
+	data, err := EnvProvider{}.Encode(&struct {
+		Dir     *string     `yaml:"dir"`
+		Port    *int        `yaml:"port"`
+		Modules []string    `yaml:"modules"`
+		Extra   interface{} `yaml:"extra"`
+	}{Port: &port, Modules: []string{"a", "b"}})
+	require.NoError(t, err)
+	require.Equal(t, "DIR=\nEXTRA=\nMODULES=[\"a\",\"b\"]\nPORT=8080\n", string(data))
+}
@@ -0,0 +1,510 @@
+package provider
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/printer"
+	"github.com/hashicorp/hcl/hcl/token"
+	"github.com/rottendev/config/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+type HCLProvider struct{}
+
+func (HCLProvider) Decode(data []byte, v interface{}) error {
+	file, err := hcl.ParseBytes(data)
+	if err != nil {
+		return err
+	}
+
+	if list, ok := file.Node.(*ast.ObjectList); ok {
+		prepareHCL(list, reflect.ValueOf(v))
+	}
+
+	return hcl.DecodeObject(v, file)
+}
+
+// DecodeStrict decodes data like Decode but rejects keys without a matching struct field.
+func (HCLProvider) DecodeStrict(data []byte, v interface{}) error {
+	file, err := hcl.ParseBytes(data)
+	if err != nil {
+		return err
+	}
+
+	list, ok := file.Node.(*ast.ObjectList)
+	if ok {
+		prepareHCL(list, reflect.ValueOf(v))
+	}
+	if err = hcl.DecodeObject(v, file); err != nil || !ok {
+		return err
+	}
+
+	var fields []UnknownField
+	hclUnknownFields(list, reflect.TypeOf(v), "", &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &UnknownFieldsError{Fields: fields}
+}
+
+// hclUnknownFields collects the keys of list without a matching field of t, like yamlUnknownFields.
+func hclUnknownFields(list *ast.ObjectList, t reflect.Type, path string, fields *[]UnknownField) {
+	t = pkg.DerefType(t)
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+
+		key := item.Keys[0]
+		keyPath := pkg.JoinPath(path, fmt.Sprint(key.Token.Value()))
+		switch t.Kind() {
+		case reflect.Map:
+			hclUnknownValue(item.Keys[1:], item.Val, t.Elem(), keyPath, fields)
+		case reflect.Struct:
+			ft, ok := lookupField(t, fmt.Sprint(key.Token.Value()), hclFieldName, true)
+			if !ok {
+				*fields = append(*fields, UnknownField{Path: keyPath, Line: key.Pos().Line, Column: key.Pos().Column})
+				continue
+			}
+			hclUnknownValue(item.Keys[1:], item.Val, ft, keyPath, fields)
+		}
+	}
+}
+
+// hclUnknownValue collects the unknown keys of the value of an item decoded into t, below the
+// labels of its block: the labels of a map are its keys, the label of a struct its key field.
+func hclUnknownValue(labels []*ast.ObjectKey, val ast.Node, t reflect.Type, path string, fields *[]UnknownField) {
+	t = pkg.DerefType(t)
+	if len(labels) > 0 {
+		switch t.Kind() {
+		case reflect.Map:
+			keyPath := pkg.JoinPath(path, fmt.Sprint(labels[0].Token.Value()))
+			hclUnknownValue(labels[1:], val, t.Elem(), keyPath, fields)
+		case reflect.Struct:
+			hclUnknownValue(nil, val, t, path, fields)
+		}
+		return
+	}
+
+	switch val := val.(type) {
+	case *ast.ObjectType:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+		hclUnknownFields(val.List, t, path, fields)
+	case *ast.ListType:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, item := range val.List {
+			hclUnknownValue(nil, item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+	}
+}
+
+// prepareHCL adapts the hcl decoder to the other providers: the repeated blocks decoded into
+// a slice of structs are merged into a single list, as the decoder would otherwise turn every
+// attribute of the blocks into a slice element, and slices present in the document are reset
+// instead of being appended to.
+func prepareHCL(list *ast.ObjectList, v reflect.Value) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("hcl"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if opts == "squash" {
+			prepareHCL(list, v.Field(i))
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fv := v.Field(i)
+		ft := pkg.DerefType(field.Type)
+		switch ft.Kind() {
+		case reflect.Struct:
+			for _, item := range list.Filter(name).Items {
+				if ot, ok := item.Val.(*ast.ObjectType); ok {
+					prepareHCL(ot.List, fv)
+				}
+			}
+		case reflect.Map:
+			for _, item := range list.Filter(name).Items {
+				if ot, ok := item.Val.(*ast.ObjectType); ok {
+					prepareHCL(ot.List, reflect.New(ft.Elem()))
+				}
+			}
+		case reflect.Slice:
+			if len(list.Filter(name).Items) > 0 && fv.CanSet() {
+				fv.Set(reflect.Zero(fv.Type()))
+			}
+			if pkg.DerefType(ft.Elem()).Kind() == reflect.Struct {
+				groupSliceBlocks(list, name, ft.Elem())
+			}
+		}
+	}
+}
+
+// groupSliceBlocks replaces the unlabelled name blocks of list with a single list of objects.
+func groupSliceBlocks(list *ast.ObjectList, name string, elem reflect.Type) {
+	var (
+		grouped *ast.ListType
+		items   = make([]*ast.ObjectItem, 0, len(list.Items))
+	)
+	for _, item := range list.Items {
+		ot, ok := item.Val.(*ast.ObjectType)
+		if !ok || len(item.Keys) != 1 || !strings.EqualFold(fmt.Sprint(item.Keys[0].Token.Value()), name) {
+			items = append(items, item)
+			continue
+		}
+
+		prepareHCL(ot.List, reflect.New(elem))
+		if grouped == nil {
+			grouped = &ast.ListType{Lbrack: ot.Lbrace, Rbrack: ot.Rbrace}
+			items = append(items, &ast.ObjectItem{Keys: item.Keys, Assign: ot.Lbrace, Val: grouped})
+		}
+		grouped.Add(ot)
+	}
+	list.Items = items
+}
+
+// Node returns the tree of data, whose keys and values keep their line and column. Blocks
+// are objects, the repeated ones and the blocks of the slices of v lists, as Decode reads
+// them; v may be nil.
+func (HCLProvider) Node(data []byte, v interface{}) (*yaml.Node, error) {
+	file, err := hcl.ParseBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := file.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("hcl: unexpected %T document", file.Node)
+	}
+	if v != nil {
+		// A zero value is prepared, as prepareHCL resets the slices of the document.
+		prepareHCL(list, reflect.New(reflect.TypeOf(v)))
+	}
+
+	return hclObjectNode(list, 1, 1), nil
+}
+
+// hclObjectNode returns the mapping of the items of list, found at line and column.
+func hclObjectNode(list *ast.ObjectList, line, column int) *yaml.Node {
+	m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: line, Column: column}
+	blocks := make(map[*yaml.Node]bool) // The lists made of repeated blocks.
+	for _, item := range list.Items {
+		node := m
+		for i, k := range item.Keys {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(k.Token.Value()),
+				Line: k.Pos().Line, Column: k.Pos().Column}
+			j := mappingIndex(node, key.Value)
+
+			if i < len(item.Keys)-1 {
+				// The labels of a block are nested objects.
+				if j < 0 || node.Content[j+1].Kind != yaml.MappingNode {
+					child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: key.Line, Column: key.Column}
+					node = setMappingValue(node, j, key, child)
+				} else {
+					node = node.Content[j+1]
+				}
+				continue
+			}
+
+			value := hclValueNode(item.Val)
+			_, block := item.Val.(*ast.ObjectType)
+			switch {
+			case j >= 0 && block && blocks[node.Content[j+1]]:
+				node.Content[j+1].Content = append(node.Content[j+1].Content, value)
+			case j >= 0 && block && node.Content[j+1].Kind == yaml.MappingNode:
+				prev := node.Content[j+1]
+				seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: prev.Line, Column: prev.Column,
+					Content: []*yaml.Node{prev, value}}
+				blocks[seq] = true
+				node.Content[j+1] = seq
+			default:
+				setMappingValue(node, j, key, value)
+			}
+		}
+	}
+
+	return m
+}
+
+// mappingIndex returns the index of key in the content of m, or -1.
+func mappingIndex(m *yaml.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// setMappingValue sets the value of key in m, at the index i of the key or appended when
+// i is negative, and returns value.
+func setMappingValue(m *yaml.Node, i int, key, value *yaml.Node) *yaml.Node {
+	if i < 0 {
+		m.Content = append(m.Content, key, value)
+	} else {
+		m.Content[i+1] = value
+	}
+
+	return value
+}
+
+// hclValueNode returns the node of an attribute value or a block.
+func hclValueNode(n ast.Node) *yaml.Node {
+	pos := n.Pos()
+	switch n := n.(type) {
+	case *ast.ObjectType:
+		return hclObjectNode(n.List, pos.Line, pos.Column)
+	case *ast.ListType:
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: pos.Line, Column: pos.Column}
+		for _, item := range n.List {
+			seq.Content = append(seq.Content, hclValueNode(item))
+		}
+		return seq
+	case *ast.LiteralType:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: n.Token.Text, Line: pos.Line, Column: pos.Column}
+		switch n.Token.Type {
+		case token.STRING, token.HEREDOC:
+			node.Tag, node.Value = "!!str", fmt.Sprint(n.Token.Value())
+		case token.NUMBER:
+			node.Tag = "!!int"
+		case token.FLOAT:
+			node.Tag = "!!float"
+		case token.BOOL:
+			node.Tag = "!!bool"
+		}
+		return node
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: pos.Line, Column: pos.Column}
+}
+
+// Encode writes v as HCL, naming attributes and blocks after their hcl tag or field name.
+// Nested structs and slices of structs become blocks, maps of structs labelled blocks.
+func (HCLProvider) Encode(v any) ([]byte, error) {
+	return encodeHCL(v, false)
+}
+
+// EncodeCommented encodes v like Encode, writing the pkg.FieldComment of every field above it.
+func (HCLProvider) EncodeCommented(v any) ([]byte, error) {
+	return encodeHCL(v, true)
+}
+
+func encodeHCL(v any, comments bool) ([]byte, error) {
+	val := reflect.ValueOf(pkg.Redact(v))
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("hcl: cannot encode %s, expected a struct", val.Kind())
+	}
+
+	b := bytes.Buffer{}
+	if err := encodeHCLBody(&b, val, comments); err != nil {
+		return nil, err
+	}
+
+	return printer.Format(b.Bytes())
+}
+
+func encodeHCLBody(b *bytes.Buffer, val reflect.Value, comments bool) error {
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("hcl"), ",")
+		switch {
+		case name == "-" || opts == "key" || opts == "decodedFields" || opts == "unusedKeys":
+			continue
+		case opts == "squash":
+			if nested, ok := hclStruct(val.Field(i)); ok {
+				if err := encodeHCLBody(b, nested, comments); err != nil {
+					return err
+				}
+			}
+			continue
+		case name == "":
+			name = field.Name
+		}
+
+		if comments && indirect(val.Field(i)).IsValid() {
+			b.WriteString(pkg.CommentLines(pkg.FieldComment(field), ""))
+		}
+		if err := encodeHCLField(b, name, val.Field(i), comments); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func encodeHCLField(b *bytes.Buffer, name string, v reflect.Value, comments bool) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if nested, ok := hclStruct(v); ok {
+		return encodeHCLBlock(b, name, "", nested, comments)
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Len() > 0 {
+			if _, ok := hclStruct(indirect(v.Index(0))); ok {
+				for i := 0; i < v.Len(); i++ {
+					nested, _ := hclStruct(indirect(v.Index(i)))
+					if err := encodeHCLBlock(b, name, "", nested, comments); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+
+		if _, ok := hclStruct(indirect(reflect.New(v.Type().Elem()).Elem())); ok {
+			for _, k := range keys {
+				nested, _ := hclStruct(indirect(v.MapIndex(k)))
+				if err := encodeHCLBlock(b, name, fmt.Sprint(k.Interface()), nested, comments); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		b.WriteString(name + " {\n")
+		for _, k := range keys {
+			value, err := hclValue(v.MapIndex(k))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(b, "%s = %s\n", strconv.Quote(fmt.Sprint(k.Interface())), value)
+		}
+		b.WriteString("}\n")
+		return nil
+	}
+
+	value, err := hclValue(v)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "%s = %s\n", name, value)
+
+	return nil
+}
+
+func encodeHCLBlock(b *bytes.Buffer, name, label string, v reflect.Value, comments bool) error {
+	b.WriteString(name)
+	if label != "" {
+		b.WriteString(" " + strconv.Quote(label))
+	}
+	b.WriteString(" {\n")
+	if err := encodeHCLBody(b, v, comments); err != nil {
+		return err
+	}
+	b.WriteString("}\n")
+
+	return nil
+}
+
+// hclValue formats a scalar or a list of scalars.
+func hclValue(v reflect.Value) (string, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return `""`, nil
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return strconv.Quote(string(text)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Slice, reflect.Array:
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := hclValue(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	default:
+		return "", fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+}
+
+// hclStruct returns the struct held by v, unless it encodes itself as text.
+func hclStruct(v reflect.Value) (reflect.Value, bool) {
+	v = indirect(v)
+	if v.Kind() != reflect.Struct {
+		return v, false
+	}
+	if _, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return v, false
+	}
+
+	return v, true
+}
+
+// indirect follows pointers and interfaces, returning an invalid value for nil ones.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
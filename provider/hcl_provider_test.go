@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type hclServer struct {
+	Host string `hcl:"host"`
+	Port int    `hcl:"port"`
+}
+
+type hclTest struct {
+	Name     string               `hcl:"name"`
+	Age      int                  `hcl:"age"`
+	Weight   float64              `hcl:"weight"`
+	Married  bool                 `hcl:"married"`
+	Hobbies  []string             `hcl:"hobbies"`
+	Timeout  time.Duration        `hcl:"timeout"`
+	Nickname *string              `hcl:"nickname"`
+	Address  hclAddress           `hcl:"address"`
+	Servers  []hclServer          `hcl:"server"`
+	Labels   map[string]string    `hcl:"labels"`
+	Backends map[string]hclServer `hcl:"backend"`
+	Ignored  string               `hcl:"-"`
+}
+
+type hclAddress struct {
+	City    string `hcl:"city"`
+	Country string
+}
+
+const hclContent = `name = "John Doe"
+
+age = 30
+
+weight = 70.5
+
+married = true
+
+hobbies = ["reading", "swimming"]
+
+timeout = 5000000000
+
+address {
+  city    = "New-York"
+  Country = "USA"
+}
+
+server {
+  host = "a.example.com"
+  port = 80
+}
+
+server {
+  host = "b.example.com"
+  port = 81
+}
+
+labels {
+  "team" = "core"
+  "tier" = "backend"
+}
+
+backend "primary" {
+  host = "db"
+  port = 5432
+}
+`
+
+func TestHCLProvider_Decode(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		p := HCLProvider{}
+		data := hclTest{}
+		err := p.Decode([]byte(hclContent), &data)
+		require.NoError(t, err)
+		require.Equal(t, "John Doe", data.Name)
+		require.Equal(t, 30, data.Age)
+		require.Equal(t, 70.5, data.Weight)
+		require.True(t, data.Married)
+		require.Equal(t, []string{"reading", "swimming"}, data.Hobbies)
+		require.Equal(t, 5*time.Second, data.Timeout)
+		require.Equal(t, "New-York", data.Address.City)
+		require.Equal(t, "USA", data.Address.Country)
+		require.Equal(t, []hclServer{{Host: "a.example.com", Port: 80}, {Host: "b.example.com", Port: 81}}, data.Servers)
+		require.Equal(t, map[string]string{"team": "core", "tier": "backend"}, data.Labels)
+		require.Equal(t, map[string]hclServer{"primary": {Host: "db", Port: 5432}}, data.Backends)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		p := HCLProvider{}
+		data := hclTest{}
+		err := p.Decode([]byte(`name = "John Doe`), &data)
+		require.Error(t, err)
+	})
+}
+
+func TestHCLProvider_Encode(t *testing.T) {
+	p := HCLProvider{}
+	data := hclTest{}
+	require.NoError(t, p.Decode([]byte(hclContent), &data))
+	data.Ignored = "ignored"
+
+	b, err := p.Encode(&data)
+	require.NoError(t, err)
+	require.Equal(t, hclContent, string(b))
+
+	decoded := hclTest{}
+	require.NoError(t, p.Decode(b, &decoded))
+	data.Ignored = ""
+	require.Equal(t, data, decoded)
+
+	_, err = p.Encode("text")
+	require.Error(t, err)
+}
@@ -0,0 +1,424 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rottendev/config/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// INIProvider decodes INI files. Sections and dotted keys map onto nested structs through
+// their yaml tags, like the YAML provider. Values are resolved like plain YAML scalars unless
+// quoted; the values of slices, arrays and maps are read as collections, see collectionNode.
+type INIProvider struct{}
+
+func (p INIProvider) Decode(data []byte, v interface{}) error {
+	node, err := p.Node(data, v)
+	if err != nil {
+		return err
+	}
+
+	return node.Decode(v)
+}
+
+// DecodeStrict decodes data like Decode but rejects keys without a matching struct field.
+func (p INIProvider) DecodeStrict(data []byte, v interface{}) error {
+	node, err := p.Node(data, v)
+	if err != nil {
+		return err
+	}
+
+	return decodeNodeStrict(node, v)
+}
+
+// Node returns the tree of data decoded by Decode, whose keys and values keep their line
+// and column. The values of the slices, arrays and maps of v are read as collections;
+// v may be nil to keep every value as read.
+func (INIProvider) Node(data []byte, v interface{}) (*yaml.Node, error) {
+	node, err := parseINI(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = collectionNodes(node, reflect.TypeOf(v)); err != nil {
+		return nil, fmt.Errorf("ini: %w", err)
+	}
+
+	return node, nil
+}
+
+// Encode writes the top-level values of v first, then a section for every nested struct or map.
+// Deeper levels use dotted keys.
+func (INIProvider) Encode(v any) ([]byte, error) {
+	root, err := encodeNode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	b := bytes.Buffer{}
+	var sections []*yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i+1].Kind == yaml.MappingNode {
+			sections = append(sections, root.Content[i], root.Content[i+1])
+			continue
+		}
+		if err = writeFlat(&b, root.Content[i].Value, root.Content[i+1], " = ", quoteINI); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < len(sections); i += 2 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", sections[i].Value)
+		for j := 0; j+1 < len(sections[i+1].Content); j += 2 {
+			key, value := sections[i+1].Content[j], sections[i+1].Content[j+1]
+			if err = writeFlat(&b, key.Value, value, " = ", quoteINI); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func parseINI(data []byte) (*yaml.Node, error) {
+	t := newTree()
+	var section []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == ';' || text[0] == '#' {
+			continue
+		}
+
+		if text[0] == '[' {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("ini: line %d: unterminated section %q", line, text)
+			}
+			section = splitKey(strings.TrimSpace(text[1 : len(text)-1]))
+			continue
+		}
+
+		sep := strings.IndexAny(text, "=:")
+		if sep < 0 {
+			return nil, fmt.Errorf("ini: line %d: expected key = value, got %q", line, text)
+		}
+		key := strings.TrimSpace(text[:sep])
+		value, err := iniValue(strings.TrimSpace(text[sep+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("ini: line %d: %w", line, err)
+		}
+
+		path := append(append([]string(nil), section...), splitKey(key)...)
+		column := strings.Index(scanner.Text(), key) + 1
+		if err = t.set(path, value, line, column); err != nil {
+			return nil, fmt.Errorf("ini: line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return t.root, nil
+}
+
+// iniValue returns the node of a value: quoted values are strings,
+// others are resolved like plain YAML scalars.
+func iniValue(value string) (*yaml.Node, error) {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		s := value[1 : len(value)-1]
+		if value[0] == '"' {
+			var err error
+			if s, err = strconv.Unquote(value); err != nil {
+				return nil, err
+			}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}, nil
+	}
+
+	return scalarNode(value), nil
+}
+
+// scalarNode returns the node of an unquoted value, kept as read until its type is known,
+// see collectionNodes.
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// collectionNodes replaces the unquoted values of node, a tree of mappings, by the collections
+// they hold when the matching value of t is a slice, an array, a map or an interface,
+// see collectionNode.
+func collectionNodes(node *yaml.Node, t reflect.Type) error {
+	if t == nil {
+		return nil
+	}
+	t = pkg.DerefType(t)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		var vt reflect.Type
+		switch t.Kind() {
+		case reflect.Map:
+			vt = t.Elem()
+		case reflect.Interface:
+			vt = t
+		case reflect.Struct:
+			ft, ok := lookupField(t, key.Value, yamlFieldName, false)
+			if !ok {
+				continue
+			}
+			vt = ft
+		default:
+			continue
+		}
+
+		if value.Kind == yaml.MappingNode {
+			if err := collectionNodes(value, vt); err != nil {
+				return err
+			}
+			continue
+		}
+		if value.Kind != yaml.ScalarNode || value.Tag != "" {
+			continue
+		}
+		collection, err := collectionNode(value, vt)
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %w", value.Line, key.Value, err)
+		}
+		node.Content[i+1] = collection
+	}
+
+	return nil
+}
+
+// collectionNode returns the node of n, an unquoted value, decoded into t. The values of
+// slices, arrays and maps starting with [ or { are read as YAML flow collections, others as
+// comma-separated items, maps with key=value items, like pkg.SetString. Interfaces hold the
+// flow collections they start with, if valid. Other values, strings in particular, are kept.
+func collectionNode(n *yaml.Node, t reflect.Type) (*yaml.Node, error) {
+	t = pkg.DerefType(t)
+	value := strings.TrimSpace(n.Value)
+	if value == "" || pkg.IsTextUnmarshaler(t) {
+		return n, nil
+	}
+	flow := value[0] == '[' || value[0] == '{'
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if !flow {
+			return n, nil
+		}
+		if collection, err := flowNode(n); err == nil {
+			return collection, nil
+		}
+		return n, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return n, nil
+		}
+		if flow {
+			return flowNode(n)
+		}
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: n.Line, Column: n.Column}
+		for _, item := range strings.Split(value, ",") {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(item), Line: n.Line, Column: n.Column})
+		}
+		return seq, nil
+	case reflect.Map:
+		if flow {
+			return flowNode(n)
+		}
+		m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: n.Line, Column: n.Column}
+		for _, item := range strings.Split(value, ",") {
+			k, v, ok := strings.Cut(item, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid map item %q, expected key=value", strings.TrimSpace(item))
+			}
+			m.Content = append(m.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(k), Line: n.Line, Column: n.Column},
+				&yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(v), Line: n.Line, Column: n.Column})
+		}
+		return m, nil
+	case reflect.Struct:
+		if flow {
+			return flowNode(n)
+		}
+	}
+
+	return n, nil
+}
+
+// flowNode parses n, an unquoted value, as a YAML flow collection.
+func flowNode(n *yaml.Node) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(n.Value), &doc); err != nil {
+		return nil, err
+	}
+	collection := doc.Content[0]
+	collection.Line, collection.Column = n.Line, n.Column
+
+	return collection, nil
+}
+
+// quoteINI quotes the strings which would otherwise be read back as another type.
+func quoteINI(n *yaml.Node) string {
+	if n.Tag == "!!str" && needsQuote(n.Value) {
+		return strconv.Quote(n.Value)
+	}
+
+	return n.Value
+}
+
+func needsQuote(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, "\n\r") {
+		return true
+	}
+	if strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{") || s[0] == '"' || s[0] == '\'' {
+		return true
+	}
+
+	var resolved interface{}
+	if err := yaml.Unmarshal([]byte(s), &resolved); err != nil {
+		return true
+	}
+	_, isString := resolved.(string)
+
+	return !isString
+}
+
+// tree builds a YAML mapping from key paths, keeping their order.
+type tree struct {
+	root *yaml.Node
+}
+
+func newTree() *tree {
+	return &tree{root: &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}}
+}
+
+func (t *tree) set(path []string, value *yaml.Node, line, column int) error {
+	node := t.root
+	for i, key := range path {
+		var child *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				child = node.Content[j+1]
+				break
+			}
+		}
+
+		last := i == len(path)-1
+		switch {
+		case child == nil && last:
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key, Line: line, Column: column}, value)
+			value.Line, value.Column = line, column
+			return nil
+		case child == nil:
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: line, Column: column}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key, Line: line, Column: column}, child)
+		case last:
+			return fmt.Errorf("duplicate key %q", strings.Join(path, "."))
+		case child.Kind != yaml.MappingNode:
+			return fmt.Errorf("key %q is both a value and a section", strings.Join(path[:i+1], "."))
+		}
+		node = child
+	}
+
+	return nil
+}
+
+// splitKey splits a dotted key, ignoring empty parts.
+func splitKey(key string) []string {
+	parts := strings.Split(key, ".")
+	path := parts[:0]
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			path = append(path, p)
+		}
+	}
+
+	return path
+}
+
+// encodeNode encodes v through its yaml tags into a mapping node, sensitive fields redacted.
+func encodeNode(v any) (*yaml.Node, error) {
+	data, err := yaml.Marshal(pkg.Redact(v))
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("cannot encode %T, expected a struct or a map", v)
+	}
+
+	return doc.Content[0], nil
+}
+
+// writeFlat writes a key and its value, flattening nested mappings into dotted keys.
+func writeFlat(b *bytes.Buffer, key string, n *yaml.Node, sep string, quote func(*yaml.Node) string) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		pairs := make([][2]*yaml.Node, 0, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+		}
+		if n.Tag == "!!map" && isMapOfScalars(pairs) {
+			sort.SliceStable(pairs, func(i, j int) bool { return pairs[i][0].Value < pairs[j][0].Value })
+		}
+		for _, p := range pairs {
+			if err := writeFlat(b, key+"."+p[0].Value, p[1], sep, quote); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.SequenceNode:
+		n.Style = yaml.FlowStyle
+		data, err := yaml.Marshal(n)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s%s%s\n", key, sep, strings.TrimSpace(string(data)))
+		return nil
+	case yaml.ScalarNode:
+		value := quote(n)
+		if n.Tag == "!!null" {
+			value = ""
+		}
+		fmt.Fprintf(b, "%s%s%s\n", key, sep, value)
+		return nil
+	default:
+		return fmt.Errorf("cannot encode %s: unsupported node", key)
+	}
+}
+
+func isMapOfScalars(pairs [][2]*yaml.Node) bool {
+	for _, p := range pairs {
+		if p[1].Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decodeNodeStrict decodes node into v, rejecting keys without a matching struct field.
+func decodeNodeStrict(node *yaml.Node, v interface{}) error {
+	var fields []UnknownField
+	yamlUnknownFields(node, reflect.TypeOf(v), "", &fields)
+	if len(fields) > 0 {
+		return &UnknownFieldsError{Fields: fields}
+	}
+
+	return node.Decode(v)
+}
@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type iniServer struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+type iniTest struct {
+	Name    string            `yaml:"name"`
+	Age     int               `yaml:"age"`
+	Married bool              `yaml:"married"`
+	Hobbies []string          `yaml:"hobbies"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Code    string            `yaml:"code"`
+	Address iniAddress        `yaml:"address"`
+	Server  iniServer         `yaml:"server"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+type iniAddress struct {
+	City    string     `yaml:"city"`
+	Country string     `yaml:"country"`
+	Geo     iniGeoInfo `yaml:"geo"`
+}
+
+type iniGeoInfo struct {
+	Lat float64 `yaml:"lat"`
+	Lng float64 `yaml:"lng"`
+}
+
+const iniContent = `name = John Doe
+age = 30
+married = true
+hobbies = [reading, swimming]
+timeout = 5s
+code = "007"
+
+[address]
+city = New-York
+country = USA
+geo.lat = 40.7
+geo.lng = -74
+
+[server]
+host = db
+port = 5432
+
+[labels]
+team = core
+tier = backend
+`
+
+func TestINIProvider_Decode(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		p := INIProvider{}
+		data := iniTest{}
+		err := p.Decode([]byte("; comment\n# comment\n"+iniContent), &data)
+		require.NoError(t, err)
+		require.Equal(t, "John Doe", data.Name)
+		require.Equal(t, 30, data.Age)
+		require.True(t, data.Married)
+		require.Equal(t, []string{"reading", "swimming"}, data.Hobbies)
+		require.Equal(t, 5*time.Second, data.Timeout)
+		require.Equal(t, "007", data.Code)
+		require.Equal(t, iniAddress{City: "New-York", Country: "USA", Geo: iniGeoInfo{Lat: 40.7, Lng: -74}}, data.Address)
+		require.Equal(t, iniServer{Host: "db", Port: 5432}, data.Server)
+		require.Equal(t, map[string]string{"team": "core", "tier": "backend"}, data.Labels)
+	})
+
+	t.Run("Dotted section", func(t *testing.T) {
+		p := INIProvider{}
+		data := iniTest{}
+		err := p.Decode([]byte("[address.geo]\nlat: 1.5\nlng: 2.5\n"), &data)
+		require.NoError(t, err)
+		require.Equal(t, iniGeoInfo{Lat: 1.5, Lng: 2.5}, data.Address.Geo)
+	})
+
+	t.Run("Collections", func(t *testing.T) {
+		p := INIProvider{}
+		data := struct {
+			Prefix  string                 `yaml:"prefix"`
+			Pattern string                 `yaml:"pat"`
+			Hobbies []string               `yaml:"hobbies"`
+			Ports   []int                  `yaml:"ports"`
+			Labels  map[string]string      `yaml:"labels"`
+			Extra   map[string]interface{} `yaml:"extra"`
+		}{}
+		err := p.Decode([]byte("prefix = [app]\npat = {x}\nhobbies = reading, swimming\nports = [80, 443]\n"+
+			"labels = team=core, tier=backend\n[extra]\nlist = [a, b]\nword = [x\n"), &data)
+		require.NoError(t, err)
+		require.Equal(t, "[app]", data.Prefix)
+		require.Equal(t, "{x}", data.Pattern)
+		require.Equal(t, []string{"reading", "swimming"}, data.Hobbies)
+		require.Equal(t, []int{80, 443}, data.Ports)
+		require.Equal(t, map[string]string{"team": "core", "tier": "backend"}, data.Labels)
+		require.Equal(t, map[string]interface{}{"list": []interface{}{"a", "b"}, "word": "[x"}, data.Extra)
+
+		err = p.Decode([]byte("labels = team\n"), &data)
+		require.EqualError(t, err, `ini: line 1: labels: invalid map item "team", expected key=value`)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		p := INIProvider{}
+		tests := map[string]string{
+			"[address\ncity = x\n":         "ini: line 1: unterminated section",
+			"name = x\nage\n":              "ini: line 2: expected key = value",
+			"name = x\nname = y\n":         `ini: line 2: duplicate key "name"`,
+			"server = x\n[server]\nhost=y": `ini: line 3: key "server" is both a value and a section`,
+		}
+		for content, want := range tests {
+			err := p.Decode([]byte(content), &iniTest{})
+			require.ErrorContains(t, err, want)
+		}
+
+		err := p.Decode([]byte("age = thirty\n"), &iniTest{})
+		require.ErrorContains(t, err, "line 1")
+	})
+}
+
+func TestINIProvider_DecodeStrict(t *testing.T) {
+	p := INIProvider{}
+	require.NoError(t, p.DecodeStrict([]byte(iniContent+"extra = 1\n"), &iniTest{}))
+
+	err := p.DecodeStrict([]byte("unknown = 1\n"+iniContent), &iniTest{})
+	require.ErrorContains(t, err, "unknown (line 1, column 1)")
+
+	err = p.DecodeStrict([]byte("name = x\n[address]\nzip = 1\n"), &iniTest{})
+	var unknown *UnknownFieldsError
+	require.ErrorAs(t, err, &unknown)
+	require.Equal(t, "address.zip", unknown.Fields[0].Path)
+}
+
+func TestINIProvider_Encode(t *testing.T) {
+	p := INIProvider{}
+	data := iniTest{}
+	require.NoError(t, p.Decode([]byte(iniContent), &data))
+
+	b, err := p.Encode(&data)
+	require.NoError(t, err)
+
+	decoded := iniTest{}
+	require.NoError(t, p.Decode(b, &decoded))
+	require.Equal(t, data, decoded)
+
+	_, err = p.Encode("text")
+	require.Error(t, err)
+}
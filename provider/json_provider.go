@@ -2,6 +2,8 @@ package provider
 
 import (
 	"encoding/json"
+
+	"github.com/rottendev/config/pkg"
 )
 
 type JSONProvider struct{}
@@ -10,6 +12,11 @@ func (JSONProvider) Decode(data []byte, v interface{}) error {
 	return json.Unmarshal(data, v)
 }
 
+// DecodeStrict decodes data like Decode but rejects keys without a matching struct field.
+func (JSONProvider) DecodeStrict(data []byte, v interface{}) error {
+	return decodeJSONStrict(data, v)
+}
+
 func (JSONProvider) Encode(v any) ([]byte, error) {
-	return json.Marshal(v)
+	return json.Marshal(pkg.Redact(v))
 }
@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PropertiesProvider decodes Java .properties files. Dotted keys map onto nested structs
+// through their yaml tags, like the YAML provider, and values are resolved like plain YAML
+// scalars; the values of slices, arrays and maps are read as collections, see collectionNode.
+type PropertiesProvider struct{}
+
+func (p PropertiesProvider) Decode(data []byte, v interface{}) error {
+	node, err := p.Node(data, v)
+	if err != nil {
+		return err
+	}
+
+	return node.Decode(v)
+}
+
+// DecodeStrict decodes data like Decode but rejects keys without a matching struct field.
+func (p PropertiesProvider) DecodeStrict(data []byte, v interface{}) error {
+	node, err := p.Node(data, v)
+	if err != nil {
+		return err
+	}
+
+	return decodeNodeStrict(node, v)
+}
+
+// Node returns the tree of data decoded by Decode, whose keys and values keep their line
+// and column. The values of the slices, arrays and maps of v are read as collections;
+// v may be nil to keep every value as read.
+func (PropertiesProvider) Node(data []byte, v interface{}) (*yaml.Node, error) {
+	node, err := parseProperties(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = collectionNodes(node, reflect.TypeOf(v)); err != nil {
+		return nil, fmt.Errorf("properties: %w", err)
+	}
+
+	return node, nil
+}
+
+// Encode writes every value of v on its own line, nested keys joined with dots.
+func (PropertiesProvider) Encode(v any) ([]byte, error) {
+	root, err := encodeNode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	b := bytes.Buffer{}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if err = writeFlat(&b, escapeProperty(root.Content[i].Value, true), root.Content[i+1], "=", quoteProperty); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func parseProperties(data []byte) (*yaml.Node, error) {
+	t := newTree()
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimLeftFunc(scanner.Text(), unicode.IsSpace)
+		if text == "" || text[0] == '#' || text[0] == '!' {
+			continue
+		}
+		start, column := line, len(scanner.Text())-len(text)+1
+
+		// A line ending with an odd number of backslashes continues on the next one.
+		for continues(text) && scanner.Scan() {
+			text = text[:len(text)-1] + strings.TrimLeftFunc(scanner.Text(), unicode.IsSpace)
+			line++
+		}
+
+		key, value, err := splitProperty(text)
+		if err != nil {
+			return nil, fmt.Errorf("properties: line %d: %w", start, err)
+		}
+		if err = t.set(splitKey(key), scalarNode(value), start, column); err != nil {
+			return nil, fmt.Errorf("properties: line %d: %w", start, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return t.root, nil
+}
+
+func continues(text string) bool {
+	n := 0
+	for i := len(text) - 1; i >= 0 && text[i] == '\\'; i-- {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+// splitProperty splits a logical line at the first unescaped '=', ':' or whitespace
+// and unescapes both parts.
+func splitProperty(text string) (key, value string, err error) {
+	end := len(text)
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] == '=' || text[i] == ':' || text[i] == ' ' || text[i] == '\t' {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(text[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	if key, err = unescapeProperty(text[:end]); err != nil {
+		return "", "", err
+	}
+	if value, err = unescapeProperty(rest); err != nil {
+		return "", "", err
+	}
+
+	return key, value, nil
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:i+5])
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+// escapeProperty escapes the characters of s which cannot appear as is in a key or value.
+func escapeProperty(s string, key bool) string {
+	b := strings.Builder{}
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case (key || i == 0) && (r == ' ' || r == '=' || r == ':' || r == '#' || r == '!'):
+			b.WriteString(`\` + string(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// quoteProperty escapes a value, leaving the strings resolved as another type unchanged
+// as properties cannot distinguish them.
+func quoteProperty(n *yaml.Node) string {
+	return escapeProperty(n.Value, false)
+}
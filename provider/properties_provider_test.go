@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type propertiesTest struct {
+	Name    string            `yaml:"name"`
+	Age     int               `yaml:"age"`
+	Married bool              `yaml:"married"`
+	Hobbies []string          `yaml:"hobbies"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Motto   string            `yaml:"motto"`
+	Address iniAddress        `yaml:"address"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+const propertiesContent = `name=John Doe
+age=30
+married=true
+hobbies=[reading, swimming]
+timeout=5s
+motto=Keep\tcalm\u0021
+address.city=New-York
+address.country=USA
+address.geo.lat=40.7
+address.geo.lng=-74
+labels.team=core
+labels.tier=backend
+`
+
+func TestPropertiesProvider_Decode(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		p := PropertiesProvider{}
+		data := propertiesTest{}
+		err := p.Decode([]byte("# comment\n! comment\n"+propertiesContent), &data)
+		require.NoError(t, err)
+		require.Equal(t, "John Doe", data.Name)
+		require.Equal(t, 30, data.Age)
+		require.True(t, data.Married)
+		require.Equal(t, []string{"reading", "swimming"}, data.Hobbies)
+		require.Equal(t, 5*time.Second, data.Timeout)
+		require.Equal(t, "Keep\tcalm!", data.Motto)
+		require.Equal(t, iniAddress{City: "New-York", Country: "USA", Geo: iniGeoInfo{Lat: 40.7, Lng: -74}}, data.Address)
+		require.Equal(t, map[string]string{"team": "core", "tier": "backend"}, data.Labels)
+	})
+
+	t.Run("Separators and continuations", func(t *testing.T) {
+		p := PropertiesProvider{}
+		data := propertiesTest{}
+		err := p.Decode([]byte("name : John \\\n    Doe\nage 30\n"), &data)
+		require.NoError(t, err)
+		require.Equal(t, "John Doe", data.Name)
+		require.Equal(t, 30, data.Age)
+	})
+
+	t.Run("Collections", func(t *testing.T) {
+		p := PropertiesProvider{}
+		data := propertiesTest{}
+		err := p.Decode([]byte("name=[app]\nmotto={x}\nhobbies=reading, swimming\nlabels=team=core\n"), &data)
+		require.NoError(t, err)
+		require.Equal(t, "[app]", data.Name)
+		require.Equal(t, "{x}", data.Motto)
+		require.Equal(t, []string{"reading", "swimming"}, data.Hobbies)
+		require.Equal(t, map[string]string{"team": "core"}, data.Labels)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		p := PropertiesProvider{}
+		err := p.Decode([]byte("name=x\nname=y\n"), &propertiesTest{})
+		require.ErrorContains(t, err, `properties: line 2: duplicate key "name"`)
+
+		err = p.Decode([]byte("motto=\\u00zz\n"), &propertiesTest{})
+		require.ErrorContains(t, err, "properties: line 1: invalid unicode escape")
+	})
+}
+
+func TestPropertiesProvider_DecodeStrict(t *testing.T) {
+	p := PropertiesProvider{}
+	err := p.DecodeStrict([]byte(propertiesContent+"address.zip=1\n"), &propertiesTest{})
+	require.ErrorContains(t, err, "address.zip (line 13, column 1)")
+}
+
+func TestPropertiesProvider_Encode(t *testing.T) {
+	p := PropertiesProvider{}
+	data := propertiesTest{}
+	require.NoError(t, p.Decode([]byte(propertiesContent), &data))
+
+	b, err := p.Encode(&data)
+	require.NoError(t, err)
+
+	decoded := propertiesTest{}
+	require.NoError(t, p.Decode(b, &decoded))
+	require.Equal(t, data, decoded)
+
+	_, err = p.Encode("text")
+	require.Error(t, err)
+}
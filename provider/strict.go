@@ -0,0 +1,312 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rottendev/config/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownField is a configuration key without a matching struct field.
+type UnknownField struct {
+	Path   string // The key path, e.g. "app.prot" or "servers[0].hots".
+	Line   int    // The line of the key, 0 when the format does not expose it.
+	Column int    // The column of the key, 0 when the format does not expose it.
+}
+
+func (f UnknownField) String() string {
+	if f.Line == 0 {
+		return f.Path
+	}
+
+	return fmt.Sprintf("%s (line %d, column %d)", f.Path, f.Line, f.Column)
+}
+
+// UnknownFieldsError lists the keys rejected by strict decoding.
+type UnknownFieldsError struct {
+	Filename string // The decoded file, empty for raw data.
+	Fields   []UnknownField
+}
+
+func (e *UnknownFieldsError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, f.String())
+	}
+
+	msg := "unknown fields: " + strings.Join(fields, ", ")
+	if e.Filename != "" {
+		msg = e.Filename + ": " + msg
+	}
+
+	return msg
+}
+
+// decodeYamlStrict decodes data with yaml.v3 KnownFields and reports every unknown key.
+func decodeYamlStrict(data []byte, v interface{}) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	err := dec.Decode(v)
+	if err == nil || !isYamlUnknownField(err) {
+		return ignoreEmptyDocument(err)
+	}
+
+	var node yaml.Node
+	if nErr := yaml.Unmarshal(data, &node); nErr != nil {
+		return err
+	}
+
+	var fields []UnknownField
+	yamlUnknownFields(&node, reflect.TypeOf(v), "", &fields)
+	if len(fields) == 0 {
+		return err
+	}
+
+	return &UnknownFieldsError{Fields: fields}
+}
+
+// ignoreEmptyDocument matches yaml.Unmarshal, which accepts empty input.
+func ignoreEmptyDocument(err error) error {
+	if err != nil && err.Error() == "EOF" {
+		return nil
+	}
+
+	return err
+}
+
+func isYamlUnknownField(err error) bool {
+	tErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return false
+	}
+	for _, msg := range tErr.Errors {
+		if strings.Contains(msg, " not found in type ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func yamlUnknownFields(node *yaml.Node, t reflect.Type, path string, fields *[]UnknownField) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			yamlUnknownFields(n, t, path, fields)
+		}
+	case yaml.AliasNode:
+		yamlUnknownFields(node.Alias, t, path, fields)
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, n := range node.Content {
+			yamlUnknownFields(n, t.Elem(), fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				yamlUnknownFields(value, t, path, fields)
+				continue
+			}
+
+			keyPath := pkg.JoinPath(path, key.Value)
+			switch t.Kind() {
+			case reflect.Map:
+				yamlUnknownFields(value, t.Elem(), keyPath, fields)
+			case reflect.Struct:
+				ft, ok := lookupField(t, key.Value, yamlFieldName, false)
+				if !ok {
+					*fields = append(*fields, UnknownField{Path: keyPath, Line: key.Line, Column: key.Column})
+					continue
+				}
+				yamlUnknownFields(value, ft, keyPath, fields)
+			}
+		}
+	}
+}
+
+// decodeJSONStrict decodes data with DisallowUnknownFields and reports every unknown key.
+func decodeJSONStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err == nil || !strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return err
+	}
+
+	w := jsonWalker{dec: json.NewDecoder(bytes.NewReader(data)), data: data}
+	if wErr := w.value(reflect.TypeOf(v), ""); wErr != nil || len(w.fields) == 0 {
+		return err
+	}
+
+	return &UnknownFieldsError{Fields: w.fields}
+}
+
+// jsonWalker collects the unknown keys of a JSON document by streaming its tokens.
+type jsonWalker struct {
+	dec    *json.Decoder
+	data   []byte
+	fields []UnknownField
+}
+
+func (w *jsonWalker) value(t reflect.Type, path string) error {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	tok, err := w.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for w.dec.More() {
+			keyTok, kErr := w.dec.Token()
+			if kErr != nil {
+				return kErr
+			}
+			key, _ := keyTok.(string)
+			keyPath := pkg.JoinPath(path, key)
+
+			var elem reflect.Type
+			switch {
+			case t == nil:
+			case t.Kind() == reflect.Map:
+				elem = t.Elem()
+			case t.Kind() == reflect.Struct:
+				ft, ok := lookupField(t, key, jsonFieldName, true)
+				if !ok {
+					line, column := pkg.Position(w.data, int(w.dec.InputOffset())-len(key)-2)
+					w.fields = append(w.fields, UnknownField{Path: keyPath, Line: line, Column: column})
+				}
+				elem = ft
+			}
+			if err = w.value(elem, keyPath); err != nil {
+				return err
+			}
+		}
+		_, err = w.dec.Token()
+	case json.Delim('['):
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i := 0; w.dec.More(); i++ {
+			if err = w.value(elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		_, err = w.dec.Token()
+	}
+
+	return err
+}
+
+// fieldNameFunc returns the key of a struct field, whether it is inlined, and false when it is ignored.
+type fieldNameFunc func(f reflect.StructField) (name string, inline bool, ok bool)
+
+func yamlFieldName(f reflect.StructField) (string, bool, bool) {
+	name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return "", false, false
+	}
+	if strings.Contains(","+opts+",", ",inline,") {
+		return "", true, true
+	}
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+
+	return name, false, true
+}
+
+func jsonFieldName(f reflect.StructField) (string, bool, bool) {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false, false
+	}
+	if name == "" && f.Anonymous {
+		return "", true, true
+	}
+	if name == "" {
+		name = f.Name
+	}
+
+	return name, false, true
+}
+
+func tomlFieldName(f reflect.StructField) (string, bool, bool) {
+	name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	if name == "-" {
+		return "", false, false
+	}
+	if name == "" && f.Anonymous {
+		return "", true, true
+	}
+	if name == "" {
+		name = f.Name
+	}
+
+	return name, false, true
+}
+
+// hclFieldName matches the hcl decoder, which only squashes embedded structs.
+func hclFieldName(f reflect.StructField) (string, bool, bool) {
+	name, opts, _ := strings.Cut(f.Tag.Get("hcl"), ",")
+	switch {
+	case name == "-" || opts == "key" || opts == "decodedFields" || opts == "unusedKeys":
+		return "", false, false
+	case f.Anonymous && opts == "squash":
+		return "", true, true
+	case name == "":
+		name = f.Name
+	}
+
+	return name, false, true
+}
+
+// lookupField returns the type of the field of t decoding key, following inlined fields.
+func lookupField(t reflect.Type, key string, nameOf fieldNameFunc, foldCase bool) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, inline, ok := nameOf(f)
+		if !ok || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+
+		if inline {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			switch ft.Kind() {
+			case reflect.Map:
+				return ft.Elem(), true
+			case reflect.Struct:
+				if found, ok := lookupField(ft, key, nameOf, foldCase); ok {
+					return found, true
+				}
+			}
+			continue
+		}
+
+		if name == key || (foldCase && strings.EqualFold(name, key)) {
+			return f.Type, true
+		}
+	}
+
+	return nil, false
+}
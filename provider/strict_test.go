@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type strictTest struct {
+	Name    string `yaml:"name" json:"name" toml:"name"`
+	Address struct {
+		City string `yaml:"city" json:"city" toml:"city"`
+	} `yaml:"address" json:"address" toml:"address"`
+	Servers []struct {
+		Host string `yaml:"host" json:"host" toml:"host"`
+	} `yaml:"servers" json:"servers" toml:"servers"`
+	Labels map[string]string `yaml:"labels" json:"labels" toml:"labels"`
+	Extra  interface{}       `yaml:"extra" json:"extra" toml:"extra"`
+	Inline struct {
+		Zone string `yaml:"zone"`
+	} `yaml:",inline"`
+}
+
+func TestDecodeStrict(t *testing.T) {
+	t.Run("Yaml", func(t *testing.T) {
+		data := []byte("name: a\nnmae: b\naddress:\n    city: c\n    ctiy: d\nservers:\n    - host: h\n    - hots: i\nlabels:\n    any: x\nextra:\n    any: y\nzone: z\n")
+
+		v := strictTest{}
+		err := YamlProvider{}.DecodeStrict(data, &v)
+		var uErr *UnknownFieldsError
+		require.ErrorAs(t, err, &uErr)
+		require.Equal(t, []UnknownField{
+			{Path: "nmae", Line: 2, Column: 1},
+			{Path: "address.ctiy", Line: 5, Column: 5},
+			{Path: "servers[1].hots", Line: 8, Column: 7},
+		}, uErr.Fields)
+		require.Equal(t, "unknown fields: nmae (line 2, column 1), address.ctiy (line 5, column 5), servers[1].hots (line 8, column 7)", err.Error())
+
+		require.NoError(t, YamlProvider{}.DecodeStrict([]byte("name: a\nzone: z\n"), &v))
+		require.Equal(t, "z", v.Inline.Zone)
+		require.NoError(t, YamlProvider{}.DecodeStrict(nil, &v))
+		require.ErrorContains(t, YamlProvider{}.DecodeStrict([]byte("name: [a"), &v), "yaml:")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		data := []byte("{\n  \"Name\": \"a\",\n  \"nmae\": \"b\",\n  \"address\": {\"city\": \"c\", \"ctiy\": \"d\"},\n" +
+			"  \"servers\": [{\"host\": \"h\"}, {\"hots\": \"i\"}],\n  \"labels\": {\"any\": \"x\"},\n  \"extra\": {\"any\": \"y\"}\n}")
+
+		v := strictTest{}
+		err := JSONProvider{}.DecodeStrict(data, &v)
+		var uErr *UnknownFieldsError
+		require.ErrorAs(t, err, &uErr)
+		require.Equal(t, []UnknownField{
+			{Path: "nmae", Line: 3, Column: 3},
+			{Path: "address.ctiy", Line: 4, Column: 28},
+			{Path: "servers[1].hots", Line: 5, Column: 31},
+		}, uErr.Fields)
+
+		require.NoError(t, JSONProvider{}.DecodeStrict([]byte(`{"name":"a","labels":{"b":"c"}}`), &v))
+		require.EqualError(t, JSONProvider{}.DecodeStrict([]byte(`{"name":1}`), &v), "json: cannot unmarshal number into Go struct field strictTest.name of type string")
+	})
+
+	t.Run("Toml", func(t *testing.T) {
+		data := []byte("name = \"a\"\nnmae = \"b\"\n[address]\ncity = \"c\"\nctiy = \"d\"\n[labels]\nany = \"x\"\n[extra]\nany = \"y\"\n")
+
+		v := strictTest{}
+		err := TomlProvider{}.DecodeStrict(data, &v)
+		require.EqualError(t, err, "unknown fields: nmae, address.ctiy")
+
+		require.NoError(t, TomlProvider{}.DecodeStrict([]byte("name = \"a\"\n"), &v))
+		require.Error(t, TomlProvider{}.DecodeStrict([]byte("name = "), &v))
+	})
+
+	t.Run("Hcl", func(t *testing.T) {
+		data := []byte("name = \"a\"\nnmae = \"b\"\naddress {\n  city = \"c\"\n  ctiy = \"d\"\n}\n" +
+			"servers {\n  host = \"h\"\n}\nservers {\n  hots = \"i\"\n}\nlabels {\n  any = \"x\"\n}\nextra {\n  any = \"y\"\n}\n")
+
+		v := strictTest{}
+		err := HCLProvider{}.DecodeStrict(data, &v)
+		var uErr *UnknownFieldsError
+		require.ErrorAs(t, err, &uErr)
+		require.Equal(t, []UnknownField{
+			{Path: "nmae", Line: 2, Column: 1},
+			{Path: "address.ctiy", Line: 5, Column: 3},
+			{Path: "servers[1].hots", Line: 11, Column: 3},
+		}, uErr.Fields)
+		require.Equal(t, "a", v.Name)
+
+		var labelled struct {
+			Services map[string]struct {
+				Port int `hcl:"port"`
+			} `hcl:"service"`
+		}
+		err = HCLProvider{}.DecodeStrict([]byte("service \"web\" {\n  port = 80\n  prot = 81\n}\n"), &labelled)
+		require.EqualError(t, err, "unknown fields: service.web.prot (line 3, column 3)")
+
+		require.NoError(t, HCLProvider{}.DecodeStrict([]byte("name = \"a\"\nlabels {\n  b = \"c\"\n}\n"), &v))
+		require.Error(t, HCLProvider{}.DecodeStrict([]byte("name = [\"a\""), &v))
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		t.Setenv("STRICT_NAME", "a")
+
+		v := strictTest{}
+		err := EnvProvider{}.DecodeStrict([]byte("name: ${STRICT_NAME}\nnmae: b\n"), &v)
+		require.EqualError(t, err, "unknown fields: nmae (line 2, column 1)")
+		require.Equal(t, "a", v.Name)
+	})
+}
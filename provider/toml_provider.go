@@ -1,7 +1,16 @@
 package provider
 
 import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
 	"github.com/BurntSushi/toml"
+	"github.com/rottendev/config/pkg"
+	"gopkg.in/yaml.v3"
 )
 
 type TomlProvider struct{}
@@ -10,6 +19,305 @@ func (TomlProvider) Decode(data []byte, v interface{}) error {
 	return toml.Unmarshal(data, v)
 }
 
+// DecodeStrict decodes data like Decode but rejects keys without a matching struct field.
+func (TomlProvider) DecodeStrict(data []byte, v interface{}) error {
+	md, err := toml.Decode(string(data), v)
+	if err != nil {
+		return err
+	}
+
+	undecoded := md.Undecoded()
+	if len(undecoded) == 0 {
+		return nil
+	}
+
+	fields := make([]UnknownField, 0, len(undecoded))
+	for _, key := range undecoded {
+		if !decodedAsAny(reflect.TypeOf(v), key) {
+			fields = append(fields, UnknownField{Path: key.String()})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &UnknownFieldsError{Fields: fields}
+}
+
+// decodedAsAny reports whether key lies below an interface value, whose keys
+// toml reports as undecoded although they are kept.
+func decodedAsAny(t reflect.Type, key toml.Key) bool {
+	for _, k := range key {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Interface:
+			return true
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			ft, ok := lookupField(t, k, tomlFieldName, true)
+			if !ok {
+				return false
+			}
+			t = ft
+		default:
+			return false
+		}
+	}
+
+	return t.Kind() == reflect.Interface
+}
+
+// Node returns the tree of data, whose keys and values keep their line and column.
+// The toml package does not expose them: they are located in data, see tomlPositions.
+func (TomlProvider) Node(data []byte, _ interface{}) (*yaml.Node, error) {
+	value := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	setTomlPositions(node, "", tomlPositions(data), tomlPosition{line: 1, key: 1, value: 1})
+
+	return node, nil
+}
+
+// tomlPosition is the line of a key and the columns of the key and of its value.
+type tomlPosition struct {
+	line, key, value int
+}
+
+// tomlPositions returns the position of the keys, tables and array tables of data by key
+// path, e.g. "servers[1].host".
+func tomlPositions(data []byte) map[string]tomlPosition {
+	positions := make(map[string]tomlPosition)
+	tables := make(map[string]int) // The number of array tables by path.
+	table := ""
+	multiline := "" // The delimiter of the multi-line string being read.
+
+	for i, line := range strings.Split(string(data), "\n") {
+		if multiline != "" {
+			if strings.Count(line, multiline)%2 == 1 {
+				multiline = ""
+			}
+			continue
+		}
+
+		text := strings.TrimSpace(line)
+		column := strings.Index(line, text) + 1
+		pos := tomlPosition{line: i + 1, key: column, value: column}
+		switch {
+		case text == "" || text[0] == '#':
+			continue
+		case text[0] == '[':
+			array := strings.HasPrefix(text, "[[")
+			parts, _, ok := tomlKey(strings.TrimLeft(text, "["))
+			if !ok {
+				continue
+			}
+			table = pkg.JoinPath(tomlTablePath(parts[:len(parts)-1], tables), parts[len(parts)-1])
+			if _, seen := positions[table]; !seen {
+				positions[table] = pos
+			}
+			if array {
+				n := tables[table]
+				tables[table] = n + 1
+				table = fmt.Sprintf("%s[%d]", table, n)
+				positions[table] = pos
+			}
+		default:
+			parts, rest, ok := tomlKey(text)
+			if !ok || !strings.HasPrefix(rest, "=") {
+				continue
+			}
+			value := strings.TrimSpace(rest[1:])
+			pos.value = strings.LastIndex(line, value) + 1
+			positions[pkg.JoinPath(table, strings.Join(parts, "."))] = pos
+			for _, delim := range []string{`"""`, `'''`} {
+				if strings.HasPrefix(value, delim) && strings.Count(value, delim)%2 == 1 {
+					multiline = delim
+				}
+			}
+		}
+	}
+
+	return positions
+}
+
+// tomlTablePath returns the path of the table named by parts, the elements of array tables
+// being the last ones read, e.g. "servers[1].tls".
+func tomlTablePath(parts []string, tables map[string]int) string {
+	path := ""
+	for _, part := range parts {
+		path = pkg.JoinPath(path, part)
+		if n, ok := tables[path]; ok {
+			path = fmt.Sprintf("%s[%d]", path, n-1)
+		}
+	}
+
+	return path
+}
+
+// tomlKey parses the dotted key s starts with, returning its parts and the rest of s
+// without its leading spaces.
+func tomlKey(s string) ([]string, string, bool) {
+	var parts []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return nil, "", false
+		}
+
+		var part string
+		switch s[0] {
+		case '"':
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, "", false
+			}
+			unquoted, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, "", false
+			}
+			part, s = unquoted, s[end+1:]
+		case '\'':
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, "", false
+			}
+			part, s = s[1:end+1], s[end+2:]
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return r != '_' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, "", false
+			}
+			part, s = s[:end], s[end:]
+		}
+
+		parts = append(parts, part)
+		s = strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(s, ".") {
+			return parts, s, true
+		}
+		s = s[1:]
+	}
+}
+
+// setTomlPositions sets the line and column of node, the value at path, and of its keys and
+// elements from positions; values without a position take the one of their parent.
+func setTomlPositions(node *yaml.Node, path string, positions map[string]tomlPosition, parent tomlPosition) {
+	pos, ok := positions[path]
+	if !ok {
+		pos = parent
+	}
+	node.Line, node.Column = pos.line, pos.value
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			setTomlPositions(n, path, positions, pos)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyPath := pkg.JoinPath(path, node.Content[i].Value)
+			key, ok := positions[keyPath]
+			if !ok {
+				key = pos
+			}
+			node.Content[i].Line, node.Content[i].Column = key.line, key.key
+			setTomlPositions(node.Content[i+1], keyPath, positions, pos)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			setTomlPositions(n, fmt.Sprintf("%s[%d]", path, i), positions, pos)
+		}
+	}
+}
+
 func (TomlProvider) Encode(v any) ([]byte, error) {
-	return toml.Marshal(v)
+	return toml.Marshal(pkg.Redact(v))
+}
+
+// EncodeCommented encodes v like Encode, writing the pkg.FieldComment of every field above its key.
+func (p TomlProvider) EncodeCommented(v any) ([]byte, error) {
+	data, err := p.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make(map[string]string)
+	tomlComments(reflect.TypeOf(v), "", comments)
+	if len(comments) == 0 {
+		return data, nil
+	}
+
+	b := bytes.Buffer{}
+	table := ""
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		var path string
+		switch {
+		case strings.HasPrefix(trimmed, "["):
+			table = strings.Trim(trimmed, "[]")
+			path = table
+		case strings.Contains(trimmed, " = "):
+			key, _, _ := strings.Cut(trimmed, " = ")
+			if unquoted, uErr := strconv.Unquote(key); uErr == nil {
+				key = unquoted
+			}
+			path = pkg.JoinPath(table, key)
+		}
+
+		b.WriteString(pkg.CommentLines(comments[path], indent))
+		b.WriteString(line)
+	}
+
+	return b.Bytes(), nil
+}
+
+// tomlComments collects the pkg.FieldComment of the fields of t by their dotted toml key.
+func tomlComments(t reflect.Type, path string, comments map[string]string) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, inline, ok := tomlFieldName(f)
+		if !ok || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if inline {
+			tomlComments(f.Type, path, comments)
+			continue
+		}
+
+		key := pkg.JoinPath(path, name)
+		if c := pkg.FieldComment(f); c != "" {
+			comments[key] = c
+		}
+		tomlComments(f.Type, key, comments)
+	}
 }
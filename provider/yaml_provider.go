@@ -1,6 +1,9 @@
 package provider
 
 import (
+	"reflect"
+
+	"github.com/rottendev/config/pkg"
 	"gopkg.in/yaml.v3"
 )
 
@@ -10,6 +13,22 @@ func (YamlProvider) Decode(data []byte, v interface{}) error {
 	return yaml.Unmarshal(data, v)
 }
 
+// DecodeStrict decodes data like Decode but rejects keys without a matching struct field.
+func (YamlProvider) DecodeStrict(data []byte, v interface{}) error {
+	return decodeYamlStrict(data, v)
+}
+
 func (YamlProvider) Encode(v any) ([]byte, error) {
-	return yaml.Marshal(v)
+	return yaml.Marshal(pkg.Redact(v))
+}
+
+// EncodeCommented encodes v like Encode, writing the pkg.FieldComment of every field above its key.
+func (YamlProvider) EncodeCommented(v any) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(pkg.Redact(v)); err != nil {
+		return nil, err
+	}
+	pkg.CommentYAML(&node, reflect.TypeOf(v))
+
+	return yaml.Marshal(&node)
 }
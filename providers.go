@@ -0,0 +1,93 @@
+package config
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/rottendev/config/provider"
+)
+
+// ProviderFactory creates the provider of a source. filename is the file of the source, if any.
+type ProviderFactory func(filename string) Provider
+
+var providerRegistry = struct {
+	sync.RWMutex
+	factories  map[Type]ProviderFactory
+	extensions map[string]Type
+}{
+	factories:  make(map[Type]ProviderFactory),
+	extensions: make(map[string]Type),
+}
+
+func init() {
+	RegisterProvider(JSONConfig, func(string) Provider { return &provider.JSONProvider{} }, ".json")
+	RegisterProvider(YamlConfig, func(string) Provider { return &provider.YamlProvider{} }, ".yaml", ".yml")
+	RegisterProvider(TomlConfig, func(string) Provider { return &provider.TomlProvider{} }, ".toml")
+	RegisterProvider(HCLConfig, func(string) Provider { return &provider.HCLProvider{} }, ".hcl")
+	RegisterProvider(INIConfig, func(string) Provider { return &provider.INIProvider{} }, ".ini", ".cfg", ".conf")
+	RegisterProvider(PropertiesConfig, func(string) Provider { return &provider.PropertiesProvider{} }, ".properties")
+	RegisterProvider(EnvConfig, func(filename string) Provider {
+		return &provider.EnvProvider{Filename: filename}
+	}, ".env")
+}
+
+// RegisterProvider makes the provider created by factory available for cfgType and lets
+// DetectConfigType recognise the file extensions as cfgType. Registering a type again
+// replaces its factory. It panics if factory is nil.
+func RegisterProvider(cfgType Type, factory ProviderFactory, extensions ...string) {
+	if factory == nil {
+		panic("config: RegisterProvider factory is nil")
+	}
+
+	providerRegistry.Lock()
+	defer providerRegistry.Unlock()
+
+	providerRegistry.factories[cfgType] = factory
+	for _, ext := range extensions {
+		providerRegistry.extensions[normalizeExtension(ext)] = cfgType
+	}
+}
+
+// RegisteredTypes returns the configuration types with a registered provider.
+func RegisteredTypes() []Type {
+	providerRegistry.RLock()
+	defer providerRegistry.RUnlock()
+
+	types := make([]Type, 0, len(providerRegistry.factories))
+	for t := range providerRegistry.factories {
+		types = append(types, t)
+	}
+
+	return types
+}
+
+// newProvider returns the provider decoding src.
+func newProvider(src Source) (Provider, error) {
+	providerRegistry.RLock()
+	factory, ok := providerRegistry.factories[src.Type]
+	providerRegistry.RUnlock()
+
+	if !ok {
+		return nil, ErrUnsupportedConfigType(src.Type)
+	}
+
+	return factory(src.Filename), nil
+}
+
+// typeOfExtension returns the type registered for the file extension.
+func typeOfExtension(ext string) (Type, bool) {
+	providerRegistry.RLock()
+	defer providerRegistry.RUnlock()
+
+	t, ok := providerRegistry.extensions[normalizeExtension(ext)]
+	return t, ok
+}
+
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	return ext
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// kvProvider decodes "Region=value" lines into testConfig.
+type kvProvider struct {
+	filename string
+}
+
+func (kvProvider) Decode(data []byte, v interface{}) error {
+	conf, ok := v.(*testConfig)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", v)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, found := strings.Cut(line, "="); found && key == "Region" {
+			conf.Region = value
+		}
+	}
+	return nil
+}
+
+func (kvProvider) Encode(v any) ([]byte, error) {
+	return []byte(fmt.Sprintf("Region=%s\n", v.(*testConfig).Region)), nil
+}
+
+func TestRegisterProvider(t *testing.T) {
+	const kvConfig Type = "kv"
+
+	var created []string
+	RegisterProvider(kvConfig, func(filename string) Provider {
+		created = append(created, filename)
+		return kvProvider{filename: filename}
+	}, "kv", ".KVS")
+
+	require.Equal(t, kvConfig, DetectConfigType("config.kv"))
+	require.Equal(t, kvConfig, DetectConfigType("config.kvs"))
+	require.Equal(t, EnvConfig, DetectConfigType("config.unknown"))
+	require.Contains(t, RegisteredTypes(), kvConfig)
+
+	filename := filepath.Join(t.TempDir(), "config.kv")
+	require.NoError(t, os.WriteFile(filename, []byte("Region=eu-west-1\n"), 0o600))
+
+	setting := testConfig{}
+	cfg, err := Load(&setting, filename, nil)
+	require.NoError(t, err)
+	require.Equal(t, "eu-west-1", setting.Region)
+	require.Equal(t, "app", setting.App.Name)
+	require.Equal(t, []string{filename}, created)
+
+	data, err := cfg.Encode()
+	require.NoError(t, err)
+	require.Equal(t, "Region=eu-west-1\n", string(data))
+
+	output := filepath.Join(t.TempDir(), "sample.kv")
+	require.Equal(t, output, ExportStructs(&testConfig{}, kvConfig, output))
+	file, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal([]byte("Region=us-west-1\n"), file))
+
+	require.Panics(t, func() { RegisterProvider("nil", nil) })
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultName is the registry name of the configuration used by LoadConfig and GetConfig.
+const DefaultName = "default"
+
+var registry = struct {
+	sync.RWMutex
+	configs map[string]*Config
+}{configs: make(map[string]*Config)}
+
+// Register stores cfg under name, replacing any configuration registered with the same name.
+func Register(name string, cfg *Config) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.configs[name] = cfg
+}
+
+// Get returns the configuration registered under name, or nil.
+func Get(name string) *Config {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return registry.configs[name]
+}
+
+// Unregister removes the configuration registered under name.
+func Unregister(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	delete(registry.configs, name)
+}
+
+// Registered returns the sorted names of the registered configurations.
+func Registered() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.configs))
+	for name := range registry.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
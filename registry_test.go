@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Independent(t *testing.T) {
+	yamlSetting := testConfig{}
+	yamlCfg, err := Load(&yamlSetting, "testdata/config.test.yaml", nil)
+	require.NoError(t, err)
+
+	tomlSetting := testConfig{}
+	tomlCfg, err := Load(&tomlSetting, "testdata/config.test.toml", nil)
+	require.NoError(t, err)
+
+	require.Equal(t, "appYaml", yamlSetting.App.Name)
+	require.Equal(t, "appToml", tomlSetting.App.Name)
+	require.Same(t, &yamlSetting, yamlCfg.Current())
+	require.Same(t, &tomlSetting, tomlCfg.Current())
+
+	_, err = Load(&testConfig{}, "testdata/config.test.a.txt", nil)
+	require.Error(t, err)
+	_, err = Load(&testConfig{}, "testdata/config.test.env.yaml", nil)
+	require.Error(t, err)
+}
+
+func TestRegistry(t *testing.T) {
+	cfg, err := WithFile("testdata/config.test.yaml")
+	require.NoError(t, err)
+
+	Register("db", cfg)
+	defer Unregister("db")
+
+	require.Same(t, cfg, Get("db"))
+	require.Nil(t, Get("flags"))
+	require.Contains(t, Registered(), "db")
+
+	Unregister("db")
+	require.Nil(t, Get("db"))
+}
+
+func TestRegistry_Concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("concurrent-%d", i%2)
+			setting := testConfig{}
+			cfg, err := Load(&setting, "testdata/config.test.yaml", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			Register(name, cfg)
+			if Get(name) == nil {
+				t.Errorf("Get(%q) = nil", name)
+			}
+			_ = Registered()
+			_, _ = cfg.Encode()
+		}(i)
+	}
+	wg.Wait()
+
+	Unregister("concurrent-0")
+	Unregister("concurrent-1")
+}
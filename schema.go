@@ -0,0 +1,376 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rottendev/config/pkg"
+)
+
+// SchemaDraft is the JSON Schema dialect of the documents of GenerateSchema.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the strings parsed by time.ParseDuration.
+const durationPattern = `^[-+]?(0|((\d+(\.\d*)?|\.\d+)(ns|us|µs|μs|ms|s|m|h))+)$`
+
+// Schema is a JSON Schema limited to the keywords describing configuration documents.
+// The boolean schemas true and false decode to an empty schema and to {"not": {}}.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 SchemaType         `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+
+	// Keys maps the keys of the documents of a format to the properties they name, when they
+	// differ, e.g. {"json": {"filesDir": "files_dir"}}. It is not a JSON Schema keyword.
+	Keys map[string]map[string]string `json:"x-keys,omitempty"`
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var b bool
+	if json.Unmarshal(data, &b) == nil {
+		*s = Schema{}
+		if !b {
+			s.Not = &Schema{}
+		}
+		return nil
+	}
+
+	// The alias has the fields of Schema without its methods.
+	type schema Schema
+	return json.Unmarshal(data, (*schema)(s))
+}
+
+// SchemaType lists the JSON types allowed by a Schema. A single type is encoded as a string.
+type SchemaType []string
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+
+	return json.Marshal([]string(t))
+}
+
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// GenerateSchema returns the JSON Schema of v, a config struct or a pointer to one.
+// Properties are named like the keys of the documents, see pkg.FieldKey, and inline
+// structs are flattened. The keys naming a property differently in the YAML, JSON, TOML or
+// HCL documents, after the tags of their decoders, are listed by format under x-keys.
+// The desc or comment tag of a field is its description, the default tag its default,
+// and the required, min, max, oneof, url, hostname and duration rules of its validate tag
+// are translated to the matching keywords. Fields with a default are not required, as the
+// default applies to the documents missing them.
+func GenerateSchema(v any) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("schema: expected a struct, got %T", v)
+	}
+
+	s, err := typeSchema(t, "", make(map[reflect.Type]bool))
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	s.Schema, s.Title = SchemaDraft, t.Name()
+
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// typeSchema returns the schema of the values of t. seen holds the structs being described,
+// whose recursive occurrences are described as any object.
+func typeSchema(t reflect.Type, path string, seen map[reflect.Type]bool) (*Schema, error) {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t, nullable = t.Elem(), true
+	}
+
+	s, err := valueSchema(t, path, seen)
+	if err != nil {
+		return nil, err
+	}
+	if nullable && len(s.Type) > 0 {
+		s.Type = append(s.Type, "null")
+	}
+
+	return s, nil
+}
+
+func valueSchema(t reflect.Type, path string, seen map[reflect.Type]bool) (*Schema, error) {
+	switch {
+	case t == durationType:
+		return &Schema{Type: SchemaType{"string", "integer"}, Pattern: durationPattern}, nil
+	case t == timeType:
+		return &Schema{Type: SchemaType{"string"}, Format: "date-time"}, nil
+	case pkg.IsTextUnmarshaler(t), t.Implements(textMarshalerType):
+		return &Schema{Type: SchemaType{"string"}}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: SchemaType{"string"}}, nil
+	case reflect.Bool:
+		return &Schema{Type: SchemaType{"boolean"}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: SchemaType{"integer"}}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: SchemaType{"integer"}, Minimum: &zero}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaType{"number"}}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: SchemaType{"string"}}, nil
+		}
+		items, err := typeSchema(t.Elem(), path+"[]", seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: SchemaType{"array"}, Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%s: unsupported map key kind %s", path, t.Key().Kind())
+		}
+		values, err := typeSchema(t.Elem(), path+".*", seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: SchemaType{"object"}, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		s := &Schema{Type: SchemaType{"object"}}
+		if seen[t] {
+			return s, nil
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		if err := structSchema(s, t, path, seen); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported kind %s", path, t.Kind())
+	}
+}
+
+// structSchema adds the fields of t to the properties of s.
+func structSchema(s *Schema, t reflect.Type, path string, seen map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if pkg.IsIgnored(field) {
+			continue
+		}
+		if pkg.IsInline(field) {
+			if err := structSchema(s, pkg.DerefType(field.Type), path, seen); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := pkg.FieldKey(field)
+		fieldPath := pkg.JoinPath(path, key)
+		for format, docKey := range documentKeys(field) {
+			if docKey == key || (format != "yaml" && strings.EqualFold(docKey, key)) {
+				continue
+			}
+			if s.Keys == nil {
+				s.Keys = make(map[string]map[string]string)
+			}
+			if s.Keys[format] == nil {
+				s.Keys[format] = make(map[string]string)
+			}
+			s.Keys[format][docKey] = key
+		}
+
+		fs, err := typeSchema(field.Type, fieldPath, seen)
+		if err != nil {
+			return err
+		}
+		fs.Description = pkg.FieldDescription(field)
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if fs.Default, err = schemaValue(field.Type, def); err != nil {
+				return fmt.Errorf("%s: default: %w", fieldPath, err)
+			}
+		}
+
+		required, err := schemaRules(fs, field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fieldPath, err)
+		}
+		// The default satisfies the rule when the key is missing.
+		if required && field.Tag.Get("default") == "" {
+			s.Required = append(s.Required, key)
+		}
+
+		if s.Properties == nil {
+			s.Properties = make(map[string]*Schema)
+		}
+		s.Properties[key] = fs
+	}
+
+	return nil
+}
+
+// documentKeys returns the key of field in the documents of each format, as named by their
+// decoders: the name of its yaml, json, toml or hcl tag, or else the lowercased field name
+// for YAML and the field name for JSON, TOML and HCL, which match keys case-insensitively.
+func documentKeys(field reflect.StructField) map[string]string {
+	keys := make(map[string]string, 4)
+	for _, format := range []string{"yaml", "json", "toml", "hcl"} {
+		name, _ := pkg.ParseTag(field, format)
+		switch {
+		case name != "":
+			keys[format] = name
+		case format == "yaml":
+			keys[format] = strings.ToLower(field.Name)
+		default:
+			keys[format] = field.Name
+		}
+	}
+
+	return keys
+}
+
+// schemaRules translates the validate tag of field into the keywords of s.
+// It reports whether the field is required.
+func schemaRules(s *Schema, field reflect.StructField) (bool, error) {
+	tag, ok := field.Tag.Lookup("validate")
+	if !ok {
+		return false, nil
+	}
+
+	t := pkg.DerefType(field.Type)
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			for _, item := range strings.Fields(param) {
+				value, err := schemaValue(t, item)
+				if err != nil {
+					return false, fmt.Errorf("oneof: %w", err)
+				}
+				s.Enum = append(s.Enum, value)
+			}
+		case "min", "max":
+			if t == durationType {
+				// Bounds are durations, which the schema cannot compare.
+				continue
+			}
+			bound, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid %s parameter %q", name, param)
+			}
+			setBound(s, t, name == "min", bound)
+		case "url":
+			s.Format = "uri"
+		case "hostname":
+			s.Format = "hostname"
+		case "duration":
+			s.Pattern = durationPattern
+		}
+	}
+
+	return required, nil
+}
+
+// setBound sets the minimum or maximum of numbers, the length of strings, slices and maps.
+func setBound(s *Schema, t reflect.Type, isMin bool, bound float64) {
+	n := int(bound)
+	switch t.Kind() {
+	case reflect.String:
+		if isMin {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array:
+		if isMin {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case reflect.Map:
+		if isMin {
+			s.MinProperties = &n
+		} else {
+			s.MaxProperties = &n
+		}
+	default:
+		if isMin {
+			s.Minimum = &bound
+		} else {
+			s.Maximum = &bound
+		}
+	}
+}
+
+// schemaValue parses s into a value of type t, see pkg.SetString, and returns it as a JSON value.
+// Values encoded as strings in the documents, such as durations, are returned as is.
+func schemaValue(t reflect.Type, s string) (any, error) {
+	t = pkg.DerefType(t)
+	if t.Kind() == reflect.String || t == durationType || pkg.IsTextUnmarshaler(t) {
+		return s, nil
+	}
+
+	v := reflect.New(t).Elem()
+	if err := pkg.SetString(v, s); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	return json.RawMessage(b), nil
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSchema(t *testing.T) {
+	type Base struct {
+		Debug bool `yaml:"debug" desc:"Enable debug logs."`
+	}
+	type Server struct {
+		Host string `yaml:"host" validate:"required,hostname"`
+		Port uint16 `yaml:"port" default:"8080" validate:"min=1"`
+	}
+	type Node struct {
+		Name     string  `yaml:"name"`
+		Children []*Node `yaml:"children"`
+	}
+	type Schemed struct {
+		Base
+		Name     string            `yaml:"name" desc:"The application name." validate:"required,min=2,max=32"`
+		Mode     string            `yaml:"mode" default:"dev" validate:"required,oneof=dev prod"`
+		Level    int               `yaml:"level" validate:"oneof=1 2 3"`
+		Ratio    float64           `yaml:"ratio" validate:"min=0,max=1"`
+		URL      string            `yaml:"url" validate:"url"`
+		Timeout  time.Duration     `yaml:"timeout" default:"5s" validate:"min=1s"`
+		Started  time.Time         `yaml:"started"`
+		Servers  []Server          `yaml:"servers" validate:"min=1"`
+		Backup   *Server           `yaml:"backup"`
+		Labels   map[string]string `yaml:"labels"`
+		Extra    interface{}       `yaml:"extra"`
+		Tree     Node              `yaml:"tree"`
+		Skipped  string            `yaml:"-"`
+		internal string
+	}
+
+	data, err := GenerateSchema(&Schemed{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Schemed",
+  "type": "object",
+  "properties": {
+    "debug": {"type": "boolean", "description": "Enable debug logs."},
+    "name": {"type": "string", "description": "The application name.", "minLength": 2, "maxLength": 32},
+    "mode": {"type": "string", "default": "dev", "enum": ["dev", "prod"]},
+    "level": {"type": "integer", "enum": [1, 2, 3]},
+    "ratio": {"type": "number", "minimum": 0, "maximum": 1},
+    "url": {"type": "string", "format": "uri"},
+    "timeout": {"type": ["string", "integer"], "pattern": "^[-+]?(0|((\\d+(\\.\\d*)?|\\.\\d+)(ns|us|µs|μs|ms|s|m|h))+)$", "default": "5s"},
+    "started": {"type": "string", "format": "date-time"},
+    "servers": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "properties": {
+          "host": {"type": "string", "format": "hostname"},
+          "port": {"type": "integer", "minimum": 1, "default": 8080}
+        },
+        "required": ["host"]
+      }
+    },
+    "backup": {
+      "type": ["object", "null"],
+      "properties": {
+        "host": {"type": "string", "format": "hostname"},
+        "port": {"type": "integer", "minimum": 1, "default": 8080}
+      },
+      "required": ["host"]
+    },
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "extra": {},
+    "tree": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"},
+        "children": {"type": "array", "items": {"type": ["object", "null"]}}
+      }
+    }
+  },
+  "required": ["name"]
+}`, string(data))
+
+	var schema Schema
+	require.NoError(t, json.Unmarshal(data, &schema))
+	require.Equal(t, SchemaType{"string", "integer"}, schema.Properties["timeout"].Type)
+	require.Equal(t, SchemaType{"string"}, schema.Properties["name"].Type)
+
+	t.Run("format keys", func(t *testing.T) {
+		data, err := GenerateSchema(&struct {
+			FilesDir string `yaml:"files_dir" json:"filesDir"`
+			Name     string `yaml:"name" toml:"title"`
+			Port     int    `json:"port" hcl:"listen"`
+		}{})
+		require.NoError(t, err)
+
+		var schema Schema
+		require.NoError(t, json.Unmarshal(data, &schema))
+		require.Equal(t, map[string]map[string]string{
+			"json": {"filesDir": "files_dir"},
+			"toml": {"FilesDir": "files_dir", "title": "name"},
+			"hcl":  {"FilesDir": "files_dir", "listen": "port"},
+		}, schema.Keys)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := GenerateSchema("config")
+		require.Error(t, err)
+		_, err = GenerateSchema(nil)
+		require.Error(t, err)
+		_, err = GenerateSchema(&struct {
+			Events chan int `yaml:"events"`
+		}{})
+		require.ErrorContains(t, err, "events: unsupported kind chan")
+		_, err = GenerateSchema(&struct {
+			Port int `yaml:"port" default:"http"`
+		}{})
+		require.ErrorContains(t, err, "port: default")
+	})
+}
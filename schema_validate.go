@@ -0,0 +1,608 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rottendev/config/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaViolation is a value of a configuration document failing its JSON Schema.
+type SchemaViolation struct {
+	Filename string // The file of the value, empty for raw data and templates.
+	Path     string // The key path, e.g. "app.port" or "servers[0].host", empty for the document.
+	Line     int    // The line of the value, 0 when the format does not expose it.
+	Column   int    // The column of the value, 0 when the format does not expose it.
+	Message  string
+}
+
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+	if v.Line > 0 {
+		path = fmt.Sprintf("%s (line %d, column %d)", path, v.Line, v.Column)
+	}
+	if v.Filename != "" {
+		path = v.Filename + ": " + path
+	}
+
+	return path + ": " + v.Message
+}
+
+// SchemaError lists every value of the configuration documents failing their JSON Schema.
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+
+	return "schema violations: " + strings.Join(msgs, "; ")
+}
+
+// ValidateDocument checks data, a document of type cfgType, against the JSON Schema schema
+// and returns a *SchemaError listing every violation. The built-in formats are supported;
+// env templates are expanded with the process environment first, and the INI and properties
+// values are kept as read, without the collections the fields of a struct would read.
+func ValidateDocument(schema []byte, cfgType Type, data []byte) error {
+	p, err := newProvider(Source{Type: cfgType})
+	if err != nil {
+		return err
+	}
+
+	return validateDocuments(schema, []schemaDocument{{src: Source{Type: cfgType}, p: p, data: data}}, nil)
+}
+
+// templateExpander is implemented by the providers of env templates, see provider.EnvProvider.
+type templateExpander interface {
+	Expand(data []byte) ([]byte, error)
+}
+
+// documentParser is implemented by the providers returning the tree of their documents with
+// the positions of the values, see provider.INIProvider.
+type documentParser interface {
+	Node(data []byte, v interface{}) (*yaml.Node, error)
+}
+
+// schemaDocument is the raw content of a layer.
+type schemaDocument struct {
+	src  Source
+	p    Provider
+	data []byte
+}
+
+// validateSchema checks the merged documents of the layers, to be decoded into conf, against
+// the schema of the config.
+func (c *Config) validateSchema(contents [][]byte, conf interface{}) error {
+	docs := make([]schemaDocument, 0, len(c.layers))
+	for i, l := range c.layers {
+		docs = append(docs, schemaDocument{src: l.src, p: l.p, data: contents[i]})
+	}
+
+	return validateDocuments(c.schema, docs, conf)
+}
+
+// validateDocuments merges docs in order, the mappings of later documents overriding the keys
+// of the previous ones, and checks the result against schema; conf is the value the documents
+// are decoded into, if any, see documentNode.
+func validateDocuments(schema []byte, docs []schemaDocument, conf interface{}) error {
+	var root Schema
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+
+	v := schemaValidator{root: &root, origins: make(map[*yaml.Node]string)}
+	var merged *yaml.Node
+	for _, doc := range docs {
+		node, err := documentNode(doc, conf)
+		if err != nil {
+			return fmt.Errorf("schema: %w", err)
+		}
+		if node == nil {
+			continue
+		}
+		v.setOrigin(node, doc.src.origin())
+		v.renameKeys(&root, node, schemaFormat(doc.src.Type))
+		merged = mergeNodes(merged, node)
+	}
+	if merged == nil {
+		merged = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	v.validate(&root, merged, "")
+	if len(v.violations) > 0 {
+		return &SchemaError{Violations: v.violations}
+	}
+
+	return nil
+}
+
+// documentNode parses the content of a layer, returning nil for empty documents. The
+// collections of formats without a syntax of their own are read as conf, if not nil, holds them.
+func documentNode(doc schemaDocument, conf interface{}) (*yaml.Node, error) {
+	var node yaml.Node
+	switch doc.src.Type {
+	case YamlConfig:
+		if err := yaml.Unmarshal(doc.data, &node); err != nil {
+			return nil, err
+		}
+	case JSONConfig:
+		// JSON documents are YAML documents, which keep the position of their values.
+		if yaml.Unmarshal(doc.data, &node) != nil {
+			var value interface{}
+			if err := json.Unmarshal(doc.data, &value); err != nil {
+				return nil, err
+			}
+			if err := node.Encode(value); err != nil {
+				return nil, err
+			}
+		}
+	case EnvConfig:
+		ep, ok := doc.p.(templateExpander)
+		if !ok || len(doc.data) == 0 {
+			// Environment only sources have no document.
+			return nil, nil
+		}
+		data, err := ep.Expand(doc.data)
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+	default:
+		dp, ok := doc.p.(documentParser)
+		if !ok {
+			return nil, fmt.Errorf("schema validation not supported by %q provider", doc.src.Type)
+		}
+		parsed, err := dp.Node(doc.data, conf)
+		if err != nil {
+			return nil, err
+		}
+		node = *parsed
+	}
+
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return node.Content[0], nil
+	}
+	if node.Kind == 0 {
+		return nil, nil
+	}
+
+	return &node, nil
+}
+
+// schemaFormat returns the format of the keys of a document of type cfgType, see Schema.Keys.
+func schemaFormat(cfgType Type) string {
+	switch cfgType {
+	case EnvConfig, INIConfig, PropertiesConfig:
+		// Env templates are YAML documents; INI and properties keys follow the yaml tags.
+		return string(YamlConfig)
+	}
+
+	return string(cfgType)
+}
+
+// renameKeys renames the keys of node, a document of format, to the properties of s they
+// name, so that documents of any format are checked and merged by property.
+func (v *schemaValidator) renameKeys(s *Schema, node *yaml.Node, format string) {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if s.Ref != "" {
+		if ref, err := v.resolve(s.Ref); err == nil {
+			s = ref
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			key.Value = s.propertyName(key.Value, format)
+			if ps, ok := s.Properties[key.Value]; ok {
+				v.renameKeys(ps, node.Content[i+1], format)
+			} else if s.AdditionalProperties != nil {
+				v.renameKeys(s.AdditionalProperties, node.Content[i+1], format)
+			}
+		}
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for _, item := range node.Content {
+				v.renameKeys(s.Items, item, format)
+			}
+		}
+	}
+}
+
+// propertyName returns the property named key in the documents of format, or key.
+// Like their decoders, JSON and TOML keys match case-insensitively.
+func (s *Schema) propertyName(key, format string) string {
+	if name, ok := s.Keys[format][key]; ok {
+		return name
+	}
+	if _, ok := s.Properties[key]; ok || format == string(YamlConfig) {
+		return key
+	}
+
+	for docKey, name := range s.Keys[format] {
+		if strings.EqualFold(docKey, key) {
+			return name
+		}
+	}
+	for name := range s.Properties {
+		if strings.EqualFold(name, key) && !renamed(s.Keys[format], name) {
+			return name
+		}
+	}
+
+	return key
+}
+
+// renamed reports whether the property name has another key in keys, the Keys of a format.
+func renamed(keys map[string]string, name string) bool {
+	for _, property := range keys {
+		if property == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeNodes merges the mapping src into dst; other nodes of src replace dst.
+func mergeNodes(dst, src *yaml.Node) *yaml.Node {
+	if dst == nil || dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return src
+	}
+
+	merged := *dst
+	merged.Content = append([]*yaml.Node(nil), dst.Content...)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		found := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				merged.Content[j], merged.Content[j+1] = key, mergeNodes(merged.Content[j+1], value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+
+	return &merged
+}
+
+// schemaValidator collects the violations of a document.
+type schemaValidator struct {
+	root       *Schema
+	origins    map[*yaml.Node]string // The file of every node.
+	violations []SchemaViolation
+}
+
+func (v *schemaValidator) setOrigin(node *yaml.Node, filename string) {
+	v.origins[node] = filename
+	for _, n := range node.Content {
+		v.setOrigin(n, filename)
+	}
+}
+
+func (v *schemaValidator) fail(node *yaml.Node, path, format string, args ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{
+		Filename: v.origins[node],
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// matches reports whether node is valid against s, without recording its violations.
+func (v *schemaValidator) matches(s *Schema, node *yaml.Node, path string) bool {
+	sub := schemaValidator{root: v.root, origins: v.origins}
+	sub.validate(s, node, path)
+
+	return len(sub.violations) == 0
+}
+
+func (v *schemaValidator) validate(s *Schema, node *yaml.Node, path string) {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	if s.Ref != "" {
+		ref, err := v.resolve(s.Ref)
+		if err != nil {
+			v.fail(node, path, "%v", err)
+		} else {
+			v.validate(ref, node, path)
+		}
+	}
+	for _, sub := range s.AllOf {
+		v.validate(sub, node, path)
+	}
+	if len(s.AnyOf) > 0 {
+		valid := false
+		for _, sub := range s.AnyOf {
+			if v.matches(sub, node, path) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			v.fail(node, path, "must match a schema of anyOf")
+		}
+	}
+	if len(s.OneOf) > 0 {
+		n := 0
+		for _, sub := range s.OneOf {
+			if v.matches(sub, node, path) {
+				n++
+			}
+		}
+		if n != 1 {
+			v.fail(node, path, "must match exactly one schema of oneOf, matches %d", n)
+		}
+	}
+	if s.Not != nil && v.matches(s.Not, node, path) {
+		if reflect.DeepEqual(*s.Not, Schema{}) {
+			v.fail(node, path, "is not allowed")
+		} else {
+			v.fail(node, path, "must not match the schema of not")
+		}
+	}
+
+	if len(s.Type) > 0 && !matchesType(s.Type, node) {
+		v.fail(node, path, "must be %s, got %s", strings.Join(s.Type, " or "), nodeType(node))
+		return
+	}
+	if s.Const != nil && !equalValues(s.Const, node) {
+		v.fail(node, path, "must be %s", formatValue(s.Const))
+	}
+	if len(s.Enum) > 0 {
+		valid := false
+		for _, e := range s.Enum {
+			if equalValues(e, node) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			items := make([]string, 0, len(s.Enum))
+			for _, e := range s.Enum {
+				items = append(items, formatValue(e))
+			}
+			v.fail(node, path, "must be one of [%s]", strings.Join(items, " "))
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		v.validateObject(s, node, path)
+	case yaml.SequenceNode:
+		v.validateArray(s, node, path)
+	case yaml.ScalarNode:
+		v.validateScalar(s, node, path)
+	}
+}
+
+// resolve returns the schema of a local reference: "#" or "#/$defs/name".
+func (v *schemaValidator) resolve(ref string) (*Schema, error) {
+	if ref == "#" {
+		return v.root, nil
+	}
+	if name, ok := strings.CutPrefix(ref, "#/$defs/"); ok {
+		if s, ok := v.root.Defs[name]; ok {
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot resolve $ref %q", ref)
+}
+
+func (v *schemaValidator) validateObject(s *Schema, node *yaml.Node, path string) {
+	keys := make(map[string]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		keys[key] = true
+		keyPath := pkg.JoinPath(path, key)
+
+		if ps, ok := s.Properties[key]; ok {
+			v.validate(ps, value, keyPath)
+		} else if s.AdditionalProperties != nil {
+			if reflect.DeepEqual(*s.AdditionalProperties, Schema{Not: &Schema{}}) {
+				v.fail(node.Content[i], keyPath, "unknown key")
+				continue
+			}
+			v.validate(s.AdditionalProperties, value, keyPath)
+		}
+	}
+
+	for _, name := range s.Required {
+		if !keys[name] {
+			v.fail(node, pkg.JoinPath(path, name), "is required")
+		}
+	}
+	if s.MinProperties != nil && len(keys) < *s.MinProperties {
+		v.fail(node, path, "must have at least %d keys", *s.MinProperties)
+	}
+	if s.MaxProperties != nil && len(keys) > *s.MaxProperties {
+		v.fail(node, path, "must have at most %d keys", *s.MaxProperties)
+	}
+}
+
+func (v *schemaValidator) validateArray(s *Schema, node *yaml.Node, path string) {
+	if s.Items != nil {
+		for i, item := range node.Content {
+			v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+	if s.MinItems != nil && len(node.Content) < *s.MinItems {
+		v.fail(node, path, "length must be at least %d", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(node.Content) > *s.MaxItems {
+		v.fail(node, path, "length must be at most %d", *s.MaxItems)
+	}
+}
+
+func (v *schemaValidator) validateScalar(s *Schema, node *yaml.Node, path string) {
+	switch nodeType(node) {
+	case "string":
+		n := utf8.RuneCountInString(node.Value)
+		if s.MinLength != nil && n < *s.MinLength {
+			v.fail(node, path, "length must be at least %d", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			v.fail(node, path, "length must be at most %d", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				v.fail(node, path, "invalid pattern %q", s.Pattern)
+			} else if !re.MatchString(node.Value) {
+				v.fail(node, path, "must match %q", s.Pattern)
+			}
+		}
+		if err := checkFormat(s.Format, node.Value); err != nil {
+			v.fail(node, path, "%v", err)
+		}
+	case "integer", "number":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			v.fail(node, path, "must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			v.fail(node, path, "must be at most %v", *s.Maximum)
+		}
+		if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+			v.fail(node, path, "must be greater than %v", *s.ExclusiveMinimum)
+		}
+		if s.ExclusiveMaximum != nil && f >= *s.ExclusiveMaximum {
+			v.fail(node, path, "must be less than %v", *s.ExclusiveMaximum)
+		}
+	}
+}
+
+// checkFormat checks the uri, hostname and date-time formats; other formats are annotations.
+func checkFormat(format, s string) error {
+	switch format {
+	case "uri":
+		if u, err := url.Parse(s); err != nil || u.Scheme == "" {
+			return errors.New("must be a valid URI")
+		}
+	case "hostname":
+		if len(s) > 253 || !hostnameRegexp.MatchString(s) {
+			return errors.New("must be a valid hostname")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return errors.New("must be a valid date-time")
+		}
+	}
+
+	return nil
+}
+
+// nodeType returns the JSON type of a node.
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return "null"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// matchesType reports whether node has one of types. Integers are numbers,
+// and numbers without a fractional part integers.
+func matchesType(types SchemaType, node *yaml.Node) bool {
+	actual := nodeType(node)
+	for _, t := range types {
+		switch {
+		case t == actual:
+			return true
+		case t == "number" && actual == "integer":
+			return true
+		case t == "integer" && actual == "number":
+			var f float64
+			if node.Decode(&f) == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// equalValues compares a schema value with a node by their JSON representation.
+func equalValues(value interface{}, node *yaml.Node) bool {
+	var decoded interface{}
+	if err := node.Decode(&decoded); err != nil {
+		return false
+	}
+
+	a, errA := jsonValue(value)
+	b, errB := jsonValue(decoded)
+
+	return errA == nil && errB == nil && reflect.DeepEqual(a, b)
+}
+
+func jsonValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	err = json.Unmarshal(b, &value)
+
+	return value, err
+}
+
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type schemaConfig struct {
+	Name    string                 `yaml:"name" toml:"name" validate:"required"`
+	Mode    string                 `yaml:"mode" toml:"mode" default:"dev" validate:"oneof=dev prod"`
+	Port    int                    `yaml:"port" toml:"port" default:"8080" validate:"min=1,max=65535"`
+	Servers []schemaServer         `yaml:"servers" toml:"servers"`
+	Plugins map[string]interface{} `yaml:"plugins" toml:"plugins"`
+}
+
+type schemaServer struct {
+	Host string `yaml:"host" toml:"host" validate:"required,hostname"`
+}
+
+func TestConfig_Schema(t *testing.T) {
+	schema, err := GenerateSchema(&schemaConfig{})
+	require.NoError(t, err)
+
+	t.Run("violations", func(t *testing.T) {
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte(`mode: staging
+port: 70000
+servers:
+  - host: api.local
+  - port: 1
+`))}, WithSchema(schema))
+		require.NoError(t, err)
+
+		err = cfg.LoadConfig(&schemaConfig{}, nil)
+		var sErr *SchemaError
+		require.ErrorAs(t, err, &sErr)
+		require.Equal(t, []SchemaViolation{
+			{Path: "mode", Line: 1, Column: 7, Message: "must be one of [dev prod]"},
+			{Path: "port", Line: 2, Column: 7, Message: "must be at most 65535"},
+			{Path: "servers[1].host", Line: 5, Column: 5, Message: "is required"},
+			{Path: "name", Line: 1, Column: 1, Message: "is required"},
+		}, sErr.Violations)
+		require.Contains(t, err.Error(), "mode (line 1, column 7): must be one of [dev prod]")
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("name: app\n"))}, WithSchema(schema))
+		require.NoError(t, err)
+
+		setting := schemaConfig{}
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, "app", setting.Name)
+		require.Equal(t, 8080, setting.Port)
+	})
+
+	t.Run("layers", func(t *testing.T) {
+		dir := t.TempDir()
+		base := filepath.Join(dir, "base.yaml")
+		require.NoError(t, os.WriteFile(base, []byte("mode: dev\nport: 0\n"), 0o600))
+
+		// The name of the overlay completes the base document.
+		cfg, err := NewLayered([]Source{FileSource(base), BytesSource(JSONConfig, []byte(`{"name": "app"}`))}, WithSchema(schema))
+		require.NoError(t, err)
+		err = cfg.LoadConfig(&schemaConfig{}, nil)
+		var sErr *SchemaError
+		require.ErrorAs(t, err, &sErr)
+		require.Equal(t, []SchemaViolation{
+			{Filename: base, Path: "port", Line: 2, Column: 7, Message: "must be at least 1"},
+		}, sErr.Violations)
+
+		cfg, err = NewLayered([]Source{FileSource(base), BytesSource(TomlConfig, []byte("port = 80\nname = \"app\"\n"))}, WithSchema(schema))
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&schemaConfig{}, nil))
+	})
+
+	t.Run("format keys", func(t *testing.T) {
+		type filesConfig struct {
+			FilesDir string `yaml:"files_dir" json:"filesDir" validate:"required"`
+			Name     string `yaml:"name" json:"name" validate:"required"`
+			MaxSize  int    `yaml:"max_size" validate:"max=10"`
+		}
+		schema, err := GenerateSchema(&filesConfig{})
+		require.NoError(t, err)
+
+		setting := filesConfig{}
+		cfg, err := NewLayered([]Source{BytesSource(JSONConfig, []byte(`{"filesDir":"x","name":"n","MaxSize":5}`))}, WithSchema(schema))
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, filesConfig{FilesDir: "x", Name: "n", MaxSize: 5}, setting)
+
+		// The keys of the JSON overlay are checked and merged like the YAML keys they name.
+		cfg, err = NewLayered([]Source{
+			BytesSource(YamlConfig, []byte("files_dir: x\n")),
+			BytesSource(JSONConfig, []byte(`{"name":"n","maxsize":20}`)),
+		}, WithSchema(schema))
+		require.NoError(t, err)
+		err = cfg.LoadConfig(&filesConfig{}, nil)
+		var sErr *SchemaError
+		require.ErrorAs(t, err, &sErr)
+		require.Equal(t, []SchemaViolation{
+			{Path: "max_size", Line: 1, Column: 23, Message: "must be at most 10"},
+		}, sErr.Violations)
+
+		err = ValidateDocument(schema, JSONConfig, []byte(`{"FILESDIR":"x"}`))
+		require.ErrorAs(t, err, &sErr)
+		require.Equal(t, []SchemaViolation{{Path: "name", Line: 1, Column: 1, Message: "is required"}}, sErr.Violations)
+	})
+
+	t.Run("map section", func(t *testing.T) {
+		handWritten := []byte(`{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$defs": {
+    "plugin": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean"},
+        "weight": {"type": "integer", "exclusiveMinimum": 0}
+      },
+      "required": ["enabled"],
+      "additionalProperties": false
+    }
+  },
+  "type": "object",
+  "properties": {
+    "plugins": {"type": "object", "additionalProperties": {"$ref": "#/$defs/plugin"}}
+  }
+}`)
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte(`name: app
+plugins:
+  cache:
+    enabled: yes
+  metrics:
+    enabled: true
+    weight: 0
+    wieght: 2
+`))}, WithSchema(handWritten))
+		require.NoError(t, err)
+
+		err = cfg.LoadConfig(&schemaConfig{}, nil)
+		var sErr *SchemaError
+		require.ErrorAs(t, err, &sErr)
+		require.Equal(t, []SchemaViolation{
+			{Path: "plugins.cache.enabled", Line: 4, Column: 14, Message: "must be boolean, got string"},
+			{Path: "plugins.metrics.weight", Line: 7, Column: 13, Message: "must be greater than 0"},
+			{Path: "plugins.metrics.wieght", Line: 8, Column: 5, Message: "unknown key"},
+		}, sErr.Violations)
+	})
+
+	t.Run("env template", func(t *testing.T) {
+		t.Setenv("SCHEMA_PORT", "0")
+		cfg, err := NewLayered([]Source{EnvSource("", []byte("name: app\nport: ${SCHEMA_PORT}\n"))}, WithSchema(schema))
+		require.NoError(t, err)
+
+		err = cfg.LoadConfig(&schemaConfig{}, nil)
+		require.EqualError(t, err, "schema violations: port (line 2, column 7): must be at least 1")
+		var sErr *SchemaError
+		require.ErrorAs(t, err, &sErr)
+		require.Equal(t, "port", sErr.Violations[0].Path)
+		require.Equal(t, 2, sErr.Violations[0].Line)
+	})
+
+	t.Run("formats", func(t *testing.T) {
+		sources := map[Type]struct {
+			data       string
+			violations []SchemaViolation
+		}{
+			INIConfig: {"mode = staging\n\n[servers]\nhost = x\n", []SchemaViolation{
+				{Path: "mode", Line: 1, Column: 1, Message: "must be one of [dev prod]"},
+				{Path: "servers", Line: 4, Column: 1, Message: "must be array, got object"},
+				{Path: "name", Line: 1, Column: 1, Message: "is required"},
+			}},
+			PropertiesConfig: {"name=app\nport=0\nservers=a.local, -b-\n", []SchemaViolation{
+				{Path: "port", Line: 2, Column: 1, Message: "must be at least 1"},
+				{Path: "servers[0]", Line: 3, Column: 1, Message: "must be object, got string"},
+				{Path: "servers[1]", Line: 3, Column: 1, Message: "must be object, got string"},
+			}},
+			TomlConfig: {"name = \"app\"\nport = 0\n\n[[servers]]\nhost = \"api.local\"\n\n[[servers]]\nhost = \"-bad-\"\n", []SchemaViolation{
+				{Path: "port", Line: 2, Column: 8, Message: "must be at least 1"},
+				{Path: "servers[1].host", Line: 8, Column: 8, Message: "must be a valid hostname"},
+			}},
+			HCLConfig: {"name = \"app\"\nmode = \"staging\"\n\nservers {\n  host = \"api.local\"\n}\n", []SchemaViolation{
+				{Path: "mode", Line: 2, Column: 8, Message: "must be one of [dev prod]"},
+			}},
+		}
+		for cfgType, src := range sources {
+			t.Run(string(cfgType), func(t *testing.T) {
+				cfg, err := NewLayered([]Source{BytesSource(cfgType, []byte(src.data))}, WithSchema(schema))
+				require.NoError(t, err)
+
+				err = cfg.LoadConfig(&schemaConfig{}, nil)
+				var sErr *SchemaError
+				require.ErrorAs(t, err, &sErr)
+				require.Equal(t, src.violations, sErr.Violations)
+			})
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := documentNode(schemaDocument{src: Source{Type: "kv"}, p: kvProvider{}, data: []byte("Region=eu\n")}, nil)
+		require.EqualError(t, err, `schema validation not supported by "kv" provider`)
+
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("name: app\n"))}, WithSchema([]byte("{")))
+		require.NoError(t, err)
+		err = cfg.LoadConfig(&schemaConfig{}, nil)
+		require.ErrorContains(t, err, "invalid schema")
+		var sErr *SchemaError
+		require.False(t, errors.As(err, &sErr))
+	})
+}
+
+func TestValidateDocument(t *testing.T) {
+	schema := []byte(`{
+  "type": "object",
+  "properties": {
+    "level": {"oneOf": [{"type": "integer"}, {"const": "auto"}]},
+    "tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "maxItems": 2},
+    "url": {"type": "string", "format": "uri"},
+    "legacy": false
+  }
+}`)
+
+	require.NoError(t, ValidateDocument(schema, JSONConfig, []byte(`{"level": "auto", "tags": ["a"]}`)))
+	require.NoError(t, ValidateDocument(schema, YamlConfig, []byte("")))
+
+	err := ValidateDocument(schema, JSONConfig, []byte(`{
+	"level": "high",
+	"tags": ["a", "B", "c"],
+	"url": "localhost",
+	"legacy": 1
+}`))
+	var sErr *SchemaError
+	require.ErrorAs(t, err, &sErr)
+	require.Equal(t, []SchemaViolation{
+		{Path: "level", Line: 2, Column: 11, Message: "must match exactly one schema of oneOf, matches 0"},
+		{Path: "tags[1]", Line: 3, Column: 16, Message: `must match "^[a-z]+$"`},
+		{Path: "tags", Line: 3, Column: 10, Message: "length must be at most 2"},
+		{Path: "url", Line: 4, Column: 9, Message: "must be a valid URI"},
+		{Path: "legacy", Line: 5, Column: 12, Message: "is not allowed"},
+	}, sErr.Violations)
+
+	require.Error(t, ValidateDocument(schema, "txt", nil))
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/rottendev/config/pkg"
+)
+
+// SecretScheme prefixes the string values resolved as secret references.
+const SecretScheme = "secret://"
+
+// DefaultSecretDir is the directory read by the default "file" secret resolver.
+const DefaultSecretDir = "/run/secrets"
+
+// SecretRef is a reference to a secret, written secret://backend/path#key in values
+// or backend/path#key in `secret` tags.
+type SecretRef struct {
+	Backend string // The name of the resolver, e.g. "vault".
+	Path    string // The secret path within the backend, without leading slash.
+	Key     string // The key within the secret, empty for the whole secret.
+}
+
+func (r SecretRef) String() string {
+	s := SecretScheme + r.Backend + "/" + r.Path
+	if r.Key != "" {
+		s += "#" + r.Key
+	}
+
+	return s
+}
+
+// ParseSecretRef parses a secret://backend/path#key reference.
+func ParseSecretRef(ref string) (SecretRef, error) {
+	if !strings.HasPrefix(ref, SecretScheme) {
+		return SecretRef{}, fmt.Errorf("invalid secret reference %q: missing %s scheme", ref, SecretScheme)
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return SecretRef{}, fmt.Errorf("invalid secret reference %q: %w", ref, err)
+	}
+	if u.Host == "" {
+		return SecretRef{}, fmt.Errorf("invalid secret reference %q: missing backend", ref)
+	}
+
+	return SecretRef{Backend: u.Host, Path: strings.TrimPrefix(u.Path, "/"), Key: u.Fragment}, nil
+}
+
+// SecretResolver returns the value of the secrets of a backend.
+type SecretResolver interface {
+	Resolve(ref SecretRef) (string, error)
+}
+
+// SecretResolverFunc adapts a function to a SecretResolver.
+type SecretResolverFunc func(ref SecretRef) (string, error)
+
+func (f SecretResolverFunc) Resolve(ref SecretRef) (string, error) {
+	return f(ref)
+}
+
+// SecretError is returned when a secret reference cannot be resolved.
+type SecretError struct {
+	Path string // The dotted key path of the field.
+	Ref  string // The secret reference.
+	Err  error
+}
+
+func (e *SecretError) Error() string {
+	return fmt.Sprintf("%s: secret %s: %v", e.Path, e.Ref, e.Err)
+}
+
+func (e *SecretError) Unwrap() error {
+	return e.Err
+}
+
+// FileSecretResolver reads secrets from files, such as Docker or Kubernetes secret mounts.
+// The secret path#key is read from Dir/path/key, or Dir/path without key.
+// Trailing newlines are removed.
+type FileSecretResolver struct {
+	Dir string // The secrets directory, DefaultSecretDir when empty.
+}
+
+func (r FileSecretResolver) Resolve(ref SecretRef) (string, error) {
+	dir := r.Dir
+	if dir == "" {
+		dir = DefaultSecretDir
+	}
+
+	name := filepath.Join(dir, filepath.FromSlash(ref.Path), ref.Key)
+	if rel, err := filepath.Rel(dir, name); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path escapes %s", dir)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// MapSecretResolver serves secrets from memory, keyed by path#key or path. It is meant for tests.
+type MapSecretResolver map[string]string
+
+func (r MapSecretResolver) Resolve(ref SecretRef) (string, error) {
+	name := ref.Path
+	if ref.Key != "" {
+		name += "#" + ref.Key
+	}
+
+	value, ok := r[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found", name)
+	}
+
+	return value, nil
+}
+
+// HTTPSecretResolver fetches secrets with a GET request to BaseURL/path, e.g. from a stub
+// server in tests. With a key the response must be a JSON object holding it.
+type HTTPSecretResolver struct {
+	BaseURL string
+	Client  *http.Client // http.DefaultClient when nil.
+}
+
+func (r HTTPSecretResolver) Resolve(ref SecretRef) (string, error) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(strings.TrimSuffix(r.BaseURL, "/") + "/" + ref.Path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if ref.Key == "" {
+		return strings.TrimRight(string(body), "\r\n"), nil
+	}
+
+	var values map[string]interface{}
+	if err = json.Unmarshal(body, &values); err != nil {
+		return "", err
+	}
+	value, ok := values[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found", ref.Key)
+	}
+	if s, isString := value.(string); isString {
+		return s, nil
+	}
+
+	return fmt.Sprint(value), nil
+}
+
+// resolveSecrets replaces the secret references of conf: string values starting with
+// secret:// and the empty fields with a `secret` tag, including those held by slices, maps
+// and interfaces.
+func (c *Config) resolveSecrets(conf interface{}) error {
+	return pkg.Walk(conf, func(f pkg.Field) error {
+		if tag, ok := f.Struct.Tag.Lookup("secret"); ok && f.Value.IsZero() {
+			value, err := c.resolveSecret(f.Path, SecretScheme+tag)
+			if err != nil {
+				return err
+			}
+			if err = pkg.SetString(f.Value, value); err != nil {
+				return &SecretError{Path: f.Path, Ref: SecretScheme + tag, Err: err}
+			}
+			return nil
+		}
+
+		v := reflect.Indirect(f.Value)
+		if v.Kind() != reflect.String || !strings.HasPrefix(v.String(), SecretScheme) {
+			return nil
+		}
+		value, err := c.resolveSecret(f.Path, v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(value)
+
+		return nil
+	}, pkg.WalkElements())
+}
+
+// resolveSecret returns the value of the secret reference ref found at path.
+func (c *Config) resolveSecret(path, ref string) (string, error) {
+	secretRef, err := ParseSecretRef(ref)
+	if err != nil {
+		return "", &SecretError{Path: path, Ref: ref, Err: err}
+	}
+
+	r, ok := c.secretResolvers[secretRef.Backend]
+	if !ok && secretRef.Backend == "file" {
+		r, ok = FileSecretResolver{}, true
+	}
+	if !ok {
+		return "", &SecretError{Path: path, Ref: ref, Err: fmt.Errorf("no resolver for backend %q", secretRef.Backend)}
+	}
+
+	value, err := r.Resolve(secretRef)
+	if err != nil {
+		return "", &SecretError{Path: path, Ref: ref, Err: err}
+	}
+
+	return value, nil
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecretRef(t *testing.T) {
+	ref, err := ParseSecretRef("secret://vault/db/prod#password")
+	require.NoError(t, err)
+	require.Equal(t, SecretRef{Backend: "vault", Path: "db/prod", Key: "password"}, ref)
+	require.Equal(t, "secret://vault/db/prod#password", ref.String())
+
+	_, err = ParseSecretRef("vault/db")
+	require.EqualError(t, err, `invalid secret reference "vault/db": missing secret:// scheme`)
+	_, err = ParseSecretRef("secret:///db")
+	require.EqualError(t, err, `invalid secret reference "secret:///db": missing backend`)
+}
+
+func TestSecretResolvers(t *testing.T) {
+	t.Run("File", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("s3cr3t\n"), 0o600))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "db"), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "db", "user"), []byte("admin"), 0o600))
+
+		r := FileSecretResolver{Dir: dir}
+		value, err := r.Resolve(SecretRef{Backend: "file", Path: "db_password"})
+		require.NoError(t, err)
+		require.Equal(t, "s3cr3t", value)
+
+		value, err = r.Resolve(SecretRef{Backend: "file", Path: "db", Key: "user"})
+		require.NoError(t, err)
+		require.Equal(t, "admin", value)
+
+		_, err = r.Resolve(SecretRef{Backend: "file", Path: "../etc/passwd"})
+		require.ErrorContains(t, err, "path escapes")
+		_, err = r.Resolve(SecretRef{Backend: "file", Path: "missing"})
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Map", func(t *testing.T) {
+		r := MapSecretResolver{"db#password": "s3cr3t", "token": "abc"}
+		value, err := r.Resolve(SecretRef{Path: "db", Key: "password"})
+		require.NoError(t, err)
+		require.Equal(t, "s3cr3t", value)
+
+		value, err = r.Resolve(SecretRef{Path: "token"})
+		require.NoError(t, err)
+		require.Equal(t, "abc", value)
+
+		_, err = r.Resolve(SecretRef{Path: "db"})
+		require.EqualError(t, err, `secret "db" not found`)
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/db":
+				_, _ = w.Write([]byte(`{"password":"s3cr3t","port":5432}`))
+			case "/token":
+				_, _ = w.Write([]byte("abc\n"))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer srv.Close()
+
+		r := HTTPSecretResolver{BaseURL: srv.URL, Client: srv.Client()}
+		value, err := r.Resolve(SecretRef{Path: "db", Key: "password"})
+		require.NoError(t, err)
+		require.Equal(t, "s3cr3t", value)
+
+		value, err = r.Resolve(SecretRef{Path: "db", Key: "port"})
+		require.NoError(t, err)
+		require.Equal(t, "5432", value)
+
+		value, err = r.Resolve(SecretRef{Path: "token"})
+		require.NoError(t, err)
+		require.Equal(t, "abc", value)
+
+		_, err = r.Resolve(SecretRef{Path: "db", Key: "user"})
+		require.EqualError(t, err, `key "user" not found`)
+		_, err = r.Resolve(SecretRef{Path: "missing"})
+		require.EqualError(t, err, "unexpected status 404 Not Found")
+	})
+}
+
+func TestConfig_Secrets(t *testing.T) {
+	type secretConfig struct {
+		DB struct {
+			User     string `yaml:"user"`
+			Password string `yaml:"password" secret:"vault/db#password"`
+			Port     int    `yaml:"port" secret:"vault/db#port"`
+		} `yaml:"db"`
+		Token   *string           `yaml:"token"`
+		Headers map[string]string `yaml:"headers"`
+	}
+
+	vault := MapSecretResolver{"db#password": "s3cr3t", "db#port": "5432", "api#token": "abc", "api#key": "xyz"}
+	data := []byte("db:\n  user: secret://vault/db#user\ntoken: secret://vault/api#token\nheaders:\n  X-Key: secret://vault/api#key\n  Accept: json\n")
+
+	setting := secretConfig{}
+	cfg, err := NewLayered([]Source{BytesSource(YamlConfig, data)}, WithSecretResolver("vault", vault))
+	require.NoError(t, err)
+	err = cfg.LoadConfig(&setting, nil)
+	var sErr *SecretError
+	require.ErrorAs(t, err, &sErr)
+	require.Equal(t, "db.user", sErr.Path)
+	require.EqualError(t, err, `resolve db.user: secret secret://vault/db#user: secret "db#user" not found`)
+
+	vault["db#user"] = "admin"
+	require.NoError(t, cfg.LoadConfig(&setting, nil))
+	require.Equal(t, "admin", setting.DB.User)
+	require.Equal(t, "s3cr3t", setting.DB.Password)
+	require.Equal(t, 5432, setting.DB.Port)
+	require.Equal(t, "abc", *setting.Token)
+	require.Equal(t, map[string]string{"X-Key": "xyz", "Accept": "json"}, setting.Headers)
+
+	t.Run("Tag does not override values", func(t *testing.T) {
+		setting := secretConfig{}
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("db:\n  password: plain\n  port: 1\n"))}, WithSecretResolver("vault", vault))
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, "plain", setting.DB.Password)
+		require.Equal(t, 1, setting.DB.Port)
+	})
+
+	t.Run("Unknown backend", func(t *testing.T) {
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("token: secret://aws/api\n"))})
+		require.NoError(t, err)
+		err = cfg.LoadConfig(&struct {
+			Token string `yaml:"token"`
+		}{}, nil)
+		require.EqualError(t, err, `resolve token: secret secret://aws/api: no resolver for backend "aws"`)
+	})
+
+	t.Run("File resolver", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("from-file\n"), 0o600))
+
+		setting := secretConfig{}
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("token: secret://file/token\n"))},
+			WithSecretResolver("file", FileSecretResolver{Dir: dir}), WithSecretResolver("vault", vault))
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, "from-file", *setting.Token)
+	})
+	t.Run("Elements", func(t *testing.T) {
+		type server struct {
+			Host     string `yaml:"host"`
+			Password string `yaml:"password"`
+			Token    string `yaml:"token" secret:"mem/api#token"`
+		}
+		setting := struct {
+			Servers []server               `yaml:"servers"`
+			ByName  map[string]server      `yaml:"by_name"`
+			Groups  [][]string             `yaml:"groups"`
+			Extra   map[string]interface{} `yaml:"extra"`
+		}{}
+		data := []byte("servers:\n  - host: a\n    token: plain\n  - host: b\n    password: secret://mem/db#pw\n" +
+			"by_name:\n  a:\n    password: secret://mem/db#pw\n    token: plain\ngroups: [[secret://mem/api#token]]\n" +
+			"extra:\n  db:\n    pw: secret://mem/db#pw\n  tokens: [secret://mem/api#token]\n")
+		mem := MapSecretResolver{"db#pw": "s3cr3t", "api#token": "abc"}
+
+		cfg, err := NewLayered([]Source{BytesSource(YamlConfig, data)}, WithSecretResolver("mem", mem))
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, []server{{Host: "a", Token: "plain"}, {Host: "b", Password: "s3cr3t", Token: "abc"}}, setting.Servers)
+		require.Equal(t, map[string]server{"a": {Password: "s3cr3t", Token: "plain"}}, setting.ByName)
+		require.Equal(t, [][]string{{"abc"}}, setting.Groups)
+		require.Equal(t, map[string]interface{}{"db": map[string]interface{}{"pw": "s3cr3t"}, "tokens": []interface{}{"abc"}}, setting.Extra)
+
+		delete(mem, "db#pw")
+		err = cfg.LoadConfig(&setting, nil)
+		require.EqualError(t, err, `resolve servers[1].password: secret secret://mem/db#pw: secret "db#pw" not found`)
+	})
+}
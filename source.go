@@ -0,0 +1,46 @@
+package config
+
+// Source describes a single configuration layer.
+// Layers are decoded in order, so values from later sources win.
+type Source struct {
+	Type     Type   // The configuration type of the layer.
+	Filename string // The file to read, or the .env file of an EnvConfig layer.
+	Data     []byte // Raw content; when set the file is not read.
+}
+
+// FileSource returns a source reading filename, with the type detected from its extension.
+func FileSource(filename string) Source {
+	return Source{Type: DetectConfigType(filename), Filename: filename}
+}
+
+// BytesSource returns a source decoding data as cfgType.
+func BytesSource(cfgType Type, data []byte) Source {
+	return Source{Type: cfgType, Data: data}
+}
+
+// EnvSource returns a source expanding the YAML template with the process environment
+// and the optional .env filename.
+func EnvSource(filename string, template []byte) Source {
+	return Source{Type: EnvConfig, Filename: filename, Data: template}
+}
+
+// EnvOnlySource returns a source populating the struct from the process environment and
+// the optional .env filename alone, without a YAML template. Variable names are derived from
+// the field names, e.g. APP_PORT for App.Port, or taken from their env tag.
+func EnvOnlySource(filename string) Source {
+	return Source{Type: EnvConfig, Filename: filename, Data: []byte{}}
+}
+
+// origin returns the file the decoded content comes from, empty for raw data and templates.
+func (s Source) origin() string {
+	if s.Type == EnvConfig {
+		return ""
+	}
+
+	return s.Filename
+}
+
+// readFile reports whether the layer content must be read from Filename.
+func (s Source) readFile() bool {
+	return s.Data == nil && s.Type != EnvConfig
+}
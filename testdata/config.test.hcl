@@ -0,0 +1,10 @@
+app {
+  name = "appHcl"
+  port = 8086
+}
+
+region = "us-west-5"
+
+filesdir = "appHcl"
+
+modules = ["module8", "module9"]
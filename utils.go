@@ -1,16 +1,18 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 
 	"github.com/rottendev/config/pkg"
 
-	"github.com/BurntSushi/toml"
 	"github.com/creasty/defaults"
 	"gopkg.in/yaml.v3"
 )
@@ -18,28 +20,23 @@ import (
 type Type string
 
 const (
-	YamlConfig Type = "yaml"
-	JSONConfig Type = "json"
-	TomlConfig Type = "toml"
-	EnvConfig  Type = "env"
+	YamlConfig       Type = "yaml"
+	JSONConfig       Type = "json"
+	TomlConfig       Type = "toml"
+	EnvConfig        Type = "env"
+	HCLConfig        Type = "hcl"
+	INIConfig        Type = "ini"
+	PropertiesConfig Type = "properties"
 )
 
 // DetectConfigType detects the type of configuration file based on its extension.
+// Unknown extensions default to EnvConfig.
 func DetectConfigType(filename string) Type {
-	extension := strings.ToLower(filepath.Ext(filename))
-
-	switch extension {
-	case ".json":
-		return JSONConfig
-	case ".yaml", ".yml":
-		return YamlConfig
-	case ".toml":
-		return TomlConfig
-	case ".env":
-		return EnvConfig
-	default:
-		return EnvConfig
+	if t, ok := typeOfExtension(filepath.Ext(filename)); ok {
+		return t
 	}
+
+	return EnvConfig
 }
 
 func outFileName(output string, cfgType Type) string {
@@ -51,71 +48,260 @@ func outFileName(output string, cfgType Type) string {
 			return "config.sample.yaml"
 		case TomlConfig:
 			return "config.sample.toml"
-		default:
+		case HCLConfig:
+			return "config.sample.hcl"
+		case INIConfig:
+			return "config.sample.ini"
+		case PropertiesConfig:
+			return "config.sample.properties"
+		case EnvConfig:
 			return "config.env.yaml"
+		default:
+			return "config.sample." + string(cfgType)
 		}
 	}
 	return output
 }
 
+// ExportStructs writes a sample configuration of structure like ExportStructsFile and returns
+// the file name. It panics on error.
 func ExportStructs(structure interface{}, cfgType Type, output string) string {
-	dErr := defaults.Set(structure)
-	if dErr != nil {
-		panic(dErr)
+	outputFile, err := ExportStructsFile(structure, cfgType, output)
+	if err != nil {
+		panic(err)
 	}
 
+	return outputFile
+}
+
+// ExportStructsFile writes a sample configuration of structure as cfgType to output, or to
+// a default file named after cfgType when output is empty, and returns the file name.
+// For EnvConfig the file holds the YAML template and the variables are written next to it,
+// to a sample named after output, e.g. sub.sample.env for sub.yaml or config.sample.env for
+// config.env.yaml; use ExportEnv to choose both destinations.
+func ExportStructsFile(structure interface{}, cfgType Type, output string) (string, error) {
 	outputFile := outFileName(output, cfgType)
-	f, err := os.Create(outputFile)
+	if cfgType == EnvConfig {
+		return outputFile, ExportEnv(structure, EnvExport{
+			Template: outputFile,
+			Sample:   envSampleName(outputFile),
+		})
+	}
+
+	b := bytes.Buffer{}
+	if err := WriteStructs(&b, structure, cfgType); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(outputFile, b.Bytes(), 0o666); err != nil {
+		return "", err
+	}
+
+	return outputFile, nil
+}
+
+// envSampleName returns the .env sample written next to the env template file template.
+func envSampleName(template string) string {
+	name := strings.TrimSuffix(template, filepath.Ext(template))
+	name = strings.TrimSuffix(name, ".env")
+
+	return name + ".sample.env"
+}
+
+// EnvExport sets the destinations of ExportEnv.
+type EnvExport struct {
+	Template string // The YAML template file, config.env.yaml when empty.
+	Sample   string // The .env sample file; none is written when empty or Combined.
+	Combined bool   // Write the defaults into the template as ${NAME:-value} instead of a sample.
+}
+
+// ExportEnv writes the EnvConfig template of structure and its .env sample to the files of opts.
+// Nothing is written when encoding fails.
+func ExportEnv(structure interface{}, opts EnvExport) error {
+	template := bytes.Buffer{}
+	sample := bytes.Buffer{}
+
+	var err error
+	if opts.Combined {
+		err = WriteEnvCombined(&template, structure)
+	} else {
+		err = WriteEnvStructs(&template, &sample, structure)
+	}
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer func() {
-		_ = f.Close()
-	}()
 
-	if cfgType == YamlConfig {
-		if err = yaml.NewEncoder(f).Encode(structure); err != nil {
-			panic(err)
-		}
+	if err = os.WriteFile(outFileName(opts.Template, EnvConfig), template.Bytes(), 0o666); err != nil {
+		return err
+	}
+	if opts.Sample == "" || opts.Combined {
+		return nil
+	}
+
+	return os.WriteFile(opts.Sample, sample.Bytes(), 0o666)
+}
+
+// WriteEnvStructs writes the EnvConfig template of structure to template and its .env sample to sample.
+func WriteEnvStructs(template, sample io.Writer, structure interface{}) error {
+	if err := WriteStructs(template, structure, EnvConfig); err != nil {
+		return err
+	}
+
+	return WriteEnvSample(sample, structure)
+}
+
+// WriteEnvCombined writes a single EnvConfig template of structure to w, carrying the
+// defaults as ${NAME:-value} so that no .env sample is needed. The variables of sensitive
+// fields, see pkg.IsSensitive, are written without a default.
+func WriteEnvCombined(w io.Writer, structure interface{}) error {
+	if err := defaults.Set(structure); err != nil {
+		return err
+	}
+
+	keys := make(map[string]interface{})
+	placeholderMap, err := pkg.GeneratePlaceholderMap(pkg.Redact(structure), keys, "")
+	if err != nil {
+		return err
+	}
+	sensitive, err := pkg.SensitivePlaceholders(structure, "")
+	if err != nil {
+		return err
 	}
-	if cfgType == JSONConfig {
-		if err = json.NewEncoder(f).Encode(structure); err != nil {
-			panic(err)
+	withDefaults(placeholderMap, keys, sensitive)
+
+	return encodeEnvTemplate(w, placeholderMap, structure)
+}
+
+// withDefaults replaces the ${NAME} placeholders of m with ${NAME:-value}, except those
+// of the sensitive names.
+func withDefaults(m map[string]interface{}, keys map[string]interface{}, sensitive map[string]bool) {
+	for k, v := range m {
+		m[k] = withDefault(v, keys, sensitive)
+	}
+}
+
+func withDefault(v interface{}, keys map[string]interface{}, sensitive map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		withDefaults(v, keys, sensitive)
+	case []interface{}:
+		for i := range v {
+			v[i] = withDefault(v[i], keys, sensitive)
+		}
+	case string:
+		name := strings.TrimSuffix(strings.TrimPrefix(v, "${"), "}")
+		if sensitive[name] {
+			return v
 		}
+		return "${" + name + ":-" + envDefault(keys[name]) + "}"
 	}
-	if cfgType == TomlConfig {
-		if err = toml.NewEncoder(f).Encode(structure); err != nil {
-			panic(err)
+
+	return v
+}
+
+// envDefault formats a default value as read back by the env provider:
+// slices and maps as JSON, nil pointers as an empty string.
+func envDefault(v interface{}) string {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return ""
 		}
+		val = val.Elem()
 	}
-	if cfgType == EnvConfig {
-		keys := make(map[string]interface{})
-		placeholderMap := pkg.GeneratePlaceholderMap(structure, keys, "")
 
-		var f1 *os.File
-		// Write envs to .env.dev file
-		f1, err = os.Create("config.sample.env")
+	switch val.Kind() {
+	case reflect.Invalid:
+		return ""
+	case reflect.Slice, reflect.Map:
+		if val.IsNil() {
+			return ""
+		}
+		b, err := json.Marshal(val.Interface())
 		if err != nil {
-			panic(err)
+			return fmt.Sprint(val.Interface())
 		}
+		return string(b)
+	default:
+		return fmt.Sprint(val.Interface())
+	}
+}
 
-		// sort keys
-		sortedKV := make([]string, 0, len(keys))
-		for k := range keys {
-			sortedKV = append(sortedKV, k)
-		}
-		sort.Strings(sortedKV)
+// WriteStructs writes a sample configuration of structure as cfgType to w, after setting
+// its defaults. Sensitive fields are masked, see pkg.Redact, and fields are documented with
+// comments when the provider is a CommentedEncoder. For EnvConfig, w receives the YAML template;
+// the variables are written by WriteEnvSample.
+func WriteStructs(w io.Writer, structure interface{}, cfgType Type) error {
+	if err := defaults.Set(structure); err != nil {
+		return err
+	}
+	structure = pkg.Redact(structure)
 
-		for _, k := range sortedKV {
-			v := keys[k]
-			_, _ = f1.WriteString(fmt.Sprintf("%s=%v\n", k, v))
+	switch cfgType {
+	case JSONConfig:
+		return json.NewEncoder(w).Encode(structure)
+	case EnvConfig:
+		placeholderMap, err := pkg.GeneratePlaceholderMap(structure, make(map[string]interface{}), "")
+		if err != nil {
+			return err
 		}
-		_ = f1.Close()
+		return encodeEnvTemplate(w, placeholderMap, structure)
+	}
 
-		if err = yaml.NewEncoder(f).Encode(placeholderMap); err != nil {
-			panic(err)
+	p, err := newProvider(Source{Type: cfgType})
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	if ce, ok := p.(CommentedEncoder); ok {
+		b, err = ce.EncodeCommented(structure)
+	} else {
+		b, err = p.Encode(structure)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+
+	return err
+}
+
+// encodeEnvTemplate writes the placeholders of structure as YAML, documented with comments.
+func encodeEnvTemplate(w io.Writer, placeholderMap map[string]interface{}, structure interface{}) error {
+	var node yaml.Node
+	if err := node.Encode(placeholderMap); err != nil {
+		return err
+	}
+	pkg.CommentYAML(&node, reflect.TypeOf(structure))
+
+	return yaml.NewEncoder(w).Encode(&node)
+}
+
+// WriteEnvSample writes the NAME=value lines of the variables of the EnvConfig template
+// of structure to w, sorted by name, after setting its defaults.
+func WriteEnvSample(w io.Writer, structure interface{}) error {
+	if err := defaults.Set(structure); err != nil {
+		return err
+	}
+
+	keys := make(map[string]interface{})
+	if _, err := pkg.GeneratePlaceholderMap(pkg.Redact(structure), keys, ""); err != nil {
+		return err
+	}
+	comments := pkg.EnvComments(reflect.TypeOf(structure), "")
+
+	// sort keys
+	sortedKV := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKV = append(sortedKV, k)
+	}
+	sort.Strings(sortedKV)
+
+	for _, k := range sortedKV {
+		if _, err := fmt.Fprintf(w, "%s%s=%v\n", pkg.CommentLines(comments[k], ""), k, keys[k]); err != nil {
+			return err
 		}
 	}
 
-	return outputFile
+	return nil
 }
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -38,6 +40,31 @@ func TestDetectConfigType(t *testing.T) {
 			filename: "config.env",
 			want:     EnvConfig,
 		},
+		{
+			name:     "hcl",
+			filename: "config.hcl",
+			want:     HCLConfig,
+		},
+		{
+			name:     "ini",
+			filename: "config.ini",
+			want:     INIConfig,
+		},
+		{
+			name:     "cfg",
+			filename: "config.cfg",
+			want:     INIConfig,
+		},
+		{
+			name:     "conf",
+			filename: "config.conf",
+			want:     INIConfig,
+		},
+		{
+			name:     "properties",
+			filename: "config.properties",
+			want:     PropertiesConfig,
+		},
 		{
 			name:     "unknown",
 			filename: "config.unknown",
@@ -73,6 +100,16 @@ Modules = ["module1", "module2"]
   Name = "app"
   Port = 8080
 `
+const hclContent = `App {
+  Name = "app"
+  Port = 8080
+}
+
+Region = "us-west-1"
+
+Modules = ["module1", "module2"]
+`
+
 const envYamlTemplate = `app:
     name: ${APP_NAME}
     port: ${APP_PORT}
@@ -82,7 +119,7 @@ region: ${REGION}
 `
 const envContent = `APP_NAME=app
 APP_PORT=8080
-FILES_DIR=<nil>
+FILES_DIR=
 MODULES=[module1 module2]
 REGION=us-west-1
 `
@@ -122,6 +159,12 @@ func TestExportStructs(t *testing.T) {
 			cfgType: TomlConfig,
 			want:    tomlContent,
 		},
+		{
+			name:    "hcl",
+			output:  "config.test.hcl",
+			cfgType: HCLConfig,
+			want:    hclContent,
+		},
 		{
 			name:    "env",
 			output:  "config.test.env.yaml",
@@ -136,7 +179,7 @@ func TestExportStructs(t *testing.T) {
 			defer func() {
 				_ = os.Remove(fileName)
 				if tt.cfgType == EnvConfig {
-					_ = os.Remove("config.sample.env")
+					_ = os.Remove("config.test.sample.env")
 				}
 			}()
 			require.Equal(t, tt.output, fileName)
@@ -149,7 +192,7 @@ func TestExportStructs(t *testing.T) {
 			require.Equal(t, tt.want, string(file))
 
 			if tt.cfgType == EnvConfig {
-				file, err = os.ReadFile("config.sample.env")
+				file, err = os.ReadFile("config.test.sample.env")
 				if err != nil {
 					t.Error(err)
 				}
@@ -159,3 +202,326 @@ func TestExportStructs(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteStructs(t *testing.T) {
+	type Conf struct {
+		App struct {
+			Name string `yaml:"name" default:"app"`
+			Port int    `yaml:"port" default:"8080"`
+		}
+		Region   string   `yaml:"region" default:"us-west-1"`
+		FilesDir *string  `yaml:"files_dir"`
+		Modules  []string `yaml:"modules" default:"[\"module1\", \"module2\"]"`
+	}
+
+	for cfgType, want := range map[Type]string{
+		YamlConfig: ymlContent,
+		JSONConfig: jsonContent,
+		TomlConfig: tomlContent,
+		HCLConfig:  hclContent,
+		EnvConfig:  envYamlTemplate,
+	} {
+		t.Run(string(cfgType), func(t *testing.T) {
+			b := bytes.Buffer{}
+			require.NoError(t, WriteStructs(&b, &Conf{}, cfgType))
+			require.Equal(t, want, b.String())
+		})
+	}
+
+	t.Run("env sample", func(t *testing.T) {
+		b := bytes.Buffer{}
+		require.NoError(t, WriteEnvSample(&b, &Conf{}))
+		require.Equal(t, envContent, b.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		b := bytes.Buffer{}
+		require.ErrorIs(t, WriteStructs(&b, &Conf{}, "txt"), ErrUnsupportedConfigType("txt"))
+
+		// defaults.Set requires a pointer to a struct.
+		require.Error(t, WriteStructs(&b, Conf{}, YamlConfig))
+		require.Error(t, WriteEnvSample(&b, Conf{}))
+
+		_, err := ExportStructsFile(&Conf{}, "txt", "config.test.txt.out")
+		require.Error(t, err)
+		_, statErr := os.Stat("config.test.txt.out")
+		require.True(t, os.IsNotExist(statErr), "no file must be written on error")
+
+		_, err = ExportStructsFile(&Conf{}, YamlConfig, filepath.Join(t.TempDir(), "missing", "config.yaml"))
+		require.Error(t, err)
+
+		require.Panics(t, func() { ExportStructs(&Conf{}, "txt", "") })
+	})
+}
+
+func TestExportEnv(t *testing.T) {
+	type Conf struct {
+		App struct {
+			Name string `yaml:"name" default:"app"`
+			Port int    `yaml:"port" default:"8080"`
+		}
+		Region   string   `yaml:"region" default:"us-west-1"`
+		FilesDir *string  `yaml:"files_dir"`
+		Modules  []string `yaml:"modules" default:"[\"module1\", \"module2\"]"`
+	}
+
+	t.Run("explicit destinations", func(t *testing.T) {
+		dir := t.TempDir()
+		template, sample := filepath.Join(dir, "app.env.yaml"), filepath.Join(dir, "app.env")
+		require.NoError(t, ExportEnv(&Conf{}, EnvExport{Template: template, Sample: sample}))
+
+		data, err := os.ReadFile(template)
+		require.NoError(t, err)
+		require.Equal(t, envYamlTemplate, string(data))
+		data, err = os.ReadFile(sample)
+		require.NoError(t, err)
+		require.Equal(t, envContent, string(data))
+
+		_, err = os.Stat("config.sample.env")
+		require.True(t, os.IsNotExist(err), "no file must be written to the working directory")
+	})
+
+	t.Run("sample next to the output", func(t *testing.T) {
+		dir := t.TempDir()
+		output, err := ExportStructsFile(&Conf{}, EnvConfig, filepath.Join(dir, "config.env.yaml"))
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, "config.env.yaml"), output)
+
+		data, err := os.ReadFile(filepath.Join(dir, "config.sample.env"))
+		require.NoError(t, err)
+		require.Equal(t, envContent, string(data))
+	})
+
+	t.Run("sample named after the output", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := ExportStructsFile(&Conf{}, EnvConfig, filepath.Join(dir, "sub.yaml"))
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(dir, "sub.sample.env"))
+		require.NoError(t, err)
+		require.Equal(t, envContent, string(data))
+		_, err = os.Stat(filepath.Join(dir, "config.sample.env"))
+		require.True(t, os.IsNotExist(err), "the sample must not be named after the default output")
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		dir := t.TempDir()
+		template := filepath.Join(dir, "config.env.yaml")
+		require.NoError(t, ExportEnv(&Conf{}, EnvExport{Template: template, Sample: filepath.Join(dir, "unused.env"), Combined: true}))
+
+		data, err := os.ReadFile(template)
+		require.NoError(t, err)
+		require.Equal(t, `app:
+    name: ${APP_NAME:-app}
+    port: ${APP_PORT:-8080}
+files_dir: ${FILES_DIR:-}
+modules: ${MODULES:-["module1","module2"]}
+region: ${REGION:-us-west-1}
+`, string(data))
+		_, err = os.Stat(filepath.Join(dir, "unused.env"))
+		require.True(t, os.IsNotExist(err))
+
+		// The combined template loads without any variable set.
+		resetEnv()
+		setting := testConfig{}
+		cfg, err := NewLayered([]Source{EnvSource("", data)})
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, "app", setting.App.Name)
+		require.Equal(t, 8080, setting.App.Port)
+		require.Equal(t, []string{"module1", "module2"}, setting.Modules)
+	})
+
+	t.Run("nested collections", func(t *testing.T) {
+		type Server struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		}
+		type Nested struct {
+			Servers []Server          `yaml:"servers"`
+			Backup  *Server           `yaml:"backup"`
+			Labels  map[string]string `yaml:"labels"`
+		}
+
+		b := bytes.Buffer{}
+		require.NoError(t, WriteEnvCombined(&b, &Nested{
+			Servers: []Server{{Host: "a", Port: 1}, {Host: "b", Port: 2}},
+			Labels:  map[string]string{"team": "core"},
+		}))
+		require.Equal(t, `backup:
+    host: ${BACKUP_HOST:-}
+    port: ${BACKUP_PORT:-0}
+labels:
+    team: ${LABELS_TEAM:-core}
+servers:
+    - host: ${SERVERS_0_HOST:-a}
+      port: ${SERVERS_0_PORT:-1}
+    - host: ${SERVERS_1_HOST:-b}
+      port: ${SERVERS_1_PORT:-2}
+`, b.String())
+
+		resetEnv()
+		t.Setenv("SERVERS_1_HOST", "c")
+		setting := Nested{}
+		cfg, err := NewLayered([]Source{EnvSource("", b.Bytes())})
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, []Server{{Host: "a", Port: 1}, {Host: "c", Port: 2}}, setting.Servers)
+		require.Equal(t, map[string]string{"team": "core"}, setting.Labels)
+	})
+
+	t.Run("sensitive", func(t *testing.T) {
+		type DB struct {
+			User     string `yaml:"user" default:"admin"`
+			Password string `yaml:"password" default:"s3cr3t" sensitive:"true"`
+		}
+		type Sensitive struct {
+			DB     DB     `yaml:"db"`
+			Keys   []DB   `yaml:"keys" redact:"true"`
+			Port   int    `yaml:"port" default:"5432" sensitive:"true"`
+			Region string `yaml:"region" default:"eu"`
+		}
+
+		b := bytes.Buffer{}
+		require.NoError(t, WriteEnvCombined(&b, &Sensitive{Keys: []DB{{User: "k"}}}))
+		require.Equal(t, `db:
+    password: ${DB_PASSWORD}
+    user: ${DB_USER:-admin}
+keys:
+    - password: ${KEYS_0_PASSWORD}
+      user: ${KEYS_0_USER}
+port: ${PORT}
+region: ${REGION:-eu}
+`, b.String())
+		require.NotContains(t, b.String(), "s3cr3t")
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		type Base struct {
+			Debug bool `yaml:"debug" default:"true"`
+		}
+		type Log struct {
+			Level string `toml:"level" default:"info"`
+		}
+		type Tagged struct {
+			Base
+			Log      `yaml:",inline"`
+			FilesDir string `json:"filesDir" default:"/tmp"`
+			Token    string `env:"API_TOKEN" default:"t"`
+			Skipped  string `yaml:"-"`
+		}
+
+		b := bytes.Buffer{}
+		require.NoError(t, WriteEnvCombined(&b, &Tagged{}))
+		require.Equal(t, `debug: ${DEBUG:-true}
+filesDir: ${FILES_DIR:-/tmp}
+level: ${LEVEL:-info}
+token: ${API_TOKEN:-t}
+`, b.String())
+
+		resetEnv()
+		t.Setenv("FILES_DIR", "/data")
+		t.Setenv("LEVEL", "debug")
+		setting := Tagged{}
+		cfg, err := NewLayered([]Source{EnvSource("", b.Bytes())})
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, Tagged{Base: Base{Debug: true}, Log: Log{Level: "debug"}, FilesDir: "/data", Token: "t"}, setting)
+
+		// The variables of the template also populate the struct without it.
+		t.Setenv("API_TOKEN", "x")
+		setting = Tagged{}
+		cfg, err = NewLayered([]Source{EnvOnlySource("")})
+		require.NoError(t, err)
+		require.NoError(t, cfg.LoadConfig(&setting, nil))
+		require.Equal(t, Tagged{Base: Base{Debug: true}, Log: Log{Level: "debug"}, FilesDir: "/data", Token: "x"}, setting)
+	})
+
+	t.Run("writer pair", func(t *testing.T) {
+		template, sample := bytes.Buffer{}, bytes.Buffer{}
+		require.NoError(t, WriteEnvStructs(&template, &sample, &Conf{}))
+		require.Equal(t, envYamlTemplate, template.String())
+		require.Equal(t, envContent, sample.String())
+	})
+}
+
+func TestWriteStructs_Comments(t *testing.T) {
+	type Conf struct {
+		App struct {
+			Name string `yaml:"name" default:"app" desc:"The application name."`
+			Port int    `yaml:"port" default:"8080" desc:"The HTTP port." validate:"required"`
+		} `desc:"Application settings."`
+		Mode string `yaml:"mode" default:"dev" validate:"oneof=dev prod"`
+	}
+
+	for cfgType, want := range map[Type]string{
+		YamlConfig: `# Application settings.
+app:
+    # The application name.
+    # Default: app.
+    name: app
+    # The HTTP port.
+    # Required. Default: 8080.
+    port: 8080
+# Allowed: dev, prod. Default: dev.
+mode: dev
+`,
+		TomlConfig: `# Allowed: dev, prod. Default: dev.
+Mode = "dev"
+
+# Application settings.
+[App]
+  # The application name.
+  # Default: app.
+  Name = "app"
+  # The HTTP port.
+  # Required. Default: 8080.
+  Port = 8080
+`,
+		HCLConfig: `# Application settings.
+App {
+  # The application name.
+  # Default: app.
+  Name = "app"
+
+  # The HTTP port.
+  # Required. Default: 8080.
+  Port = 8080
+}
+
+# Allowed: dev, prod. Default: dev.
+Mode = "dev"
+`,
+		EnvConfig: `# Application settings.
+app:
+    # The application name.
+    # Default: app.
+    name: ${APP_NAME}
+    # The HTTP port.
+    # Required. Default: 8080.
+    port: ${APP_PORT}
+# Allowed: dev, prod. Default: dev.
+mode: ${MODE}
+`,
+	} {
+		t.Run(string(cfgType), func(t *testing.T) {
+			b := bytes.Buffer{}
+			require.NoError(t, WriteStructs(&b, &Conf{}, cfgType))
+			require.Equal(t, want, b.String())
+		})
+	}
+
+	t.Run("env sample", func(t *testing.T) {
+		b := bytes.Buffer{}
+		require.NoError(t, WriteEnvSample(&b, &Conf{}))
+		require.Equal(t, `# The application name.
+# Default: app.
+APP_NAME=app
+# The HTTP port.
+# Required. Default: 8080.
+APP_PORT=8080
+# Allowed: dev, prod. Default: dev.
+MODE=dev
+`, b.String())
+	})
+}
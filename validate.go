@@ -0,0 +1,238 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rottendev/config/pkg"
+)
+
+// Validator is implemented by configuration structs checking themselves after decoding.
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError describes a field failing validation.
+type ValidationError struct {
+	Path string // The dotted key path of the field, empty for the root struct.
+	Rule string // The failing rule, e.g. "min=1", empty for Validate methods.
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors lists every field failing validation.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, vErr := range e {
+		msgs = append(msgs, vErr.Error())
+	}
+
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, vErr := range e {
+		errs = append(errs, vErr)
+	}
+
+	return errs
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// Validate checks the `validate` tags of the struct pointed to by v and calls the Validate
+// methods of v and its nested structs, including the structs held by slices and maps, which
+// are reported under paths like "servers[0].host". Supported rules are required, min, max,
+// oneof, url, hostname and duration. Empty strings only fail the required rule.
+func Validate(v interface{}) error {
+	var errs ValidationErrors
+	if vErr := callValidate(reflect.ValueOf(v), ""); vErr != nil {
+		errs = append(errs, vErr)
+	}
+
+	err := pkg.Walk(v, func(f pkg.Field) error {
+		if tag, ok := f.Struct.Tag.Lookup("validate"); ok {
+			for _, rule := range strings.Split(tag, ",") {
+				if rErr := checkRule(f.Value, rule); rErr != nil {
+					errs = append(errs, &ValidationError{Path: f.Path, Rule: rule, Err: rErr})
+					// The remaining rules of a missing value only repeat the failure.
+					if rule == "required" {
+						break
+					}
+				}
+			}
+		}
+
+		if vErr := callValidate(f.Value, f.Path); vErr != nil {
+			errs = append(errs, vErr)
+		}
+
+		return nil
+	}, pkg.WalkElements())
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// callValidate calls the Validate method of v, if any.
+func callValidate(v reflect.Value, path string) *ValidationError {
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		v = v.Addr()
+	}
+	if (v.Kind() == reflect.Ptr && v.IsNil()) || !v.Type().Implements(validatorType) {
+		return nil
+	}
+
+	if err := v.Interface().(Validator).Validate(); err != nil {
+		return &ValidationError{Path: path, Err: err}
+	}
+
+	return nil
+}
+
+var hostnameRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// checkRule checks a single validation rule against v.
+func checkRule(v reflect.Value, rule string) error {
+	name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if name == "required" {
+				return errors.New("is required")
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch name {
+	case "":
+		return nil
+	case "required":
+		if isEmpty(v) {
+			return errors.New("is required")
+		}
+		return nil
+	case "min", "max":
+		return checkBound(v, name, param)
+	}
+
+	if v.Kind() == reflect.String && v.Len() == 0 {
+		return nil
+	}
+
+	switch name {
+	case "oneof":
+		allowed := strings.Fields(param)
+		value := fmt.Sprint(v.Interface())
+		for _, a := range allowed {
+			if a == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s]", strings.Join(allowed, " "))
+	case "url":
+		u, err := url.Parse(v.String())
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("must be a valid URL")
+		}
+	case "hostname":
+		if s := v.String(); len(s) > 253 || !hostnameRegexp.MatchString(s) {
+			return errors.New("must be a valid hostname")
+		}
+	case "duration":
+		if v.Type() == durationType {
+			return nil
+		}
+		if _, err := time.ParseDuration(v.String()); err != nil {
+			return errors.New("must be a valid duration")
+		}
+	default:
+		return fmt.Errorf("unknown validation rule %q", name)
+	}
+
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// checkBound checks the min and max rules: the value of numbers and durations,
+// the length of strings, slices and maps.
+func checkBound(v reflect.Value, name, param string) error {
+	var value, bound float64
+	var err error
+	subject := "must be"
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(v.Int())
+		if v.Type() == durationType {
+			var d time.Duration
+			d, err = time.ParseDuration(param)
+			bound = float64(d)
+		} else {
+			bound, err = strconv.ParseFloat(param, 64)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(v.Uint())
+		bound, err = strconv.ParseFloat(param, 64)
+	case reflect.Float32, reflect.Float64:
+		value = v.Float()
+		bound, err = strconv.ParseFloat(param, 64)
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		value = float64(v.Len())
+		bound, err = strconv.ParseFloat(param, 64)
+		subject = "length must be"
+	default:
+		return fmt.Errorf("rule %s does not apply to %s", name, v.Kind())
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s parameter %q", name, param)
+	}
+
+	if name == "min" && value < bound {
+		return fmt.Errorf("%s at least %s", subject, param)
+	}
+	if name == "max" && value > bound {
+		return fmt.Errorf("%s at most %s", subject, param)
+	}
+
+	return nil
+}
+
+// isEmpty reports whether v holds the zero value or an empty collection.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type validatedServer struct {
+	Host string `yaml:"host" validate:"required,hostname"`
+	Port int    `yaml:"port" validate:"min=1,max=65535"`
+}
+
+func (s validatedServer) Validate() error {
+	if s.Host == "forbidden" {
+		return errors.New("host is forbidden")
+	}
+	return nil
+}
+
+type validatedConfig struct {
+	App struct {
+		Name string `yaml:"name" validate:"required,max=8"`
+		Port int    `yaml:"port" validate:"required,min=1,max=65535"`
+		Mode string `yaml:"mode" validate:"oneof=debug release"`
+	} `yaml:"app"`
+	URL      string           `yaml:"url" validate:"url"`
+	Timeout  string           `yaml:"timeout" validate:"duration"`
+	Interval time.Duration    `yaml:"interval" validate:"min=1s"`
+	Tags     []string         `yaml:"tags" validate:"min=1"`
+	Server   *validatedServer `yaml:"server"`
+}
+
+func (c *validatedConfig) Validate() error {
+	if c.App.Mode == "debug" && c.App.Port == 80 {
+		return errors.New("debug mode cannot listen on port 80")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		setting := validatedConfig{}
+		setting.App.Name = "app"
+		setting.App.Port = 8080
+		setting.App.Mode = "release"
+		setting.URL = "https://example.com/path"
+		setting.Timeout = "5s"
+		setting.Interval = time.Minute
+		setting.Tags = []string{"a"}
+		setting.Server = &validatedServer{Host: "db.example.com", Port: 5432}
+		require.NoError(t, Validate(&setting))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		setting := validatedConfig{}
+		setting.App.Name = "too-long-name"
+		setting.App.Mode = "debug"
+		setting.URL = "example.com"
+		setting.Timeout = "5 seconds"
+		setting.Interval = time.Millisecond
+		setting.Server = &validatedServer{Host: "-bad-", Port: 70000}
+
+		err := Validate(&setting)
+		require.Error(t, err)
+
+		var vErrs ValidationErrors
+		require.ErrorAs(t, err, &vErrs)
+		paths := make([]string, 0, len(vErrs))
+		for _, vErr := range vErrs {
+			paths = append(paths, vErr.Path)
+		}
+		require.Equal(t, []string{
+			"app.name", "app.port", "url", "timeout", "interval", "tags", "server.host", "server.port",
+		}, paths)
+		require.Equal(t, "max=8", vErrs[0].Rule)
+		require.Equal(t, "validation failed: app.name: length must be at most 8; app.port: is required; "+
+			"url: must be a valid URL; timeout: must be a valid duration; interval: must be at least 1s; "+
+			"tags: length must be at least 1; server.host: must be a valid hostname; server.port: must be at most 65535",
+			err.Error())
+
+		var vErr *ValidationError
+		require.ErrorAs(t, err, &vErr)
+		require.Equal(t, "app.name", vErr.Path)
+	})
+
+	t.Run("Validate methods", func(t *testing.T) {
+		setting := validatedConfig{}
+		setting.App.Name = "app"
+		setting.App.Port = 80
+		setting.App.Mode = "debug"
+		setting.Tags = []string{"a"}
+		setting.Interval = time.Second
+		setting.Server = &validatedServer{Host: "forbidden", Port: 1}
+
+		err := Validate(&setting)
+		require.EqualError(t, err, "validation failed: debug mode cannot listen on port 80; server: host is forbidden")
+	})
+
+	t.Run("oneof", func(t *testing.T) {
+		setting := validatedConfig{}
+		setting.App.Name = "app"
+		setting.App.Port = 1
+		setting.App.Mode = "trace"
+		setting.Tags = []string{"a"}
+		setting.Interval = time.Second
+
+		err := Validate(&setting)
+		require.EqualError(t, err, "validation failed: app.mode: must be one of [debug release]")
+	})
+
+	t.Run("Elements", func(t *testing.T) {
+		type logConf struct {
+			Level string `yaml:"level" validate:"oneof=debug info"`
+		}
+		setting := struct {
+			Log     logConf                      `yaml:",inline"`
+			Servers []validatedServer            `yaml:"servers"`
+			Backups []*validatedServer           `yaml:"backups"`
+			ByName  map[string]validatedServer   `yaml:"by_name"`
+			Groups  map[string][]validatedServer `yaml:"groups"`
+			Extra   map[string]interface{}       `yaml:"extra"`
+		}{
+			Log:     logConf{Level: "trace"},
+			Servers: []validatedServer{{}, {Host: "db", Port: 1}},
+			Backups: []*validatedServer{nil, {Host: "forbidden", Port: 1}},
+			ByName:  map[string]validatedServer{"a": {Host: "db", Port: 70000}},
+			Groups:  map[string][]validatedServer{"b": {{Host: "-bad-", Port: 1}}},
+			Extra:   map[string]interface{}{"c": validatedServer{Host: "db"}},
+		}
+
+		err := Validate(&setting)
+		require.EqualError(t, err, "validation failed: level: must be one of [debug info]; "+
+			"servers[0].host: is required; servers[0].port: must be at least 1; backups[1]: host is forbidden; "+
+			"by_name.a.port: must be at most 65535; groups.b[0].host: must be a valid hostname; extra.c.port: must be at least 1")
+
+		var vErrs ValidationErrors
+		require.ErrorAs(t, err, &vErrs)
+		require.Equal(t, "oneof=debug info", vErrs[0].Rule)
+	})
+
+	t.Run("Invalid rules", func(t *testing.T) {
+		setting := struct {
+			Name string `validate:"email"`
+			Port int    `validate:"min=a"`
+		}{Name: "a"}
+		require.EqualError(t, Validate(&setting), "validation failed: name: unknown validation rule \"email\"; port: invalid min parameter \"a\"")
+		require.Error(t, Validate(setting))
+	})
+}
+
+func TestConfig_LoadConfigValidation(t *testing.T) {
+	cfg, err := NewLayered([]Source{BytesSource(YamlConfig, []byte("app:\n    name: app\n    prot: 8080\ntags: [a]\ninterval: 1s\n"))})
+	require.NoError(t, err)
+
+	setting := validatedConfig{}
+	err = cfg.LoadConfig(&setting, nil)
+	require.EqualError(t, err, "validation failed: app.port: is required")
+	require.Nil(t, cfg.Current())
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+)
+
+// ChangeFunc is called after a successful reload with the previous and the new configuration.
+type ChangeFunc func(old, updated interface{})
+
+// fileState is the polled state of a watched file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// OnChange registers fn to be called after every successful reload.
+func (c *Config) OnChange(fn ChangeFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onChange = append(c.onChange, fn)
+}
+
+// OnReloadError registers fn to be called when a reload fails.
+// The previous configuration is kept in that case.
+func (c *Config) OnReloadError(fn func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onError = append(c.onError, fn)
+}
+
+// Watch polls the configuration files, including the .env files of env sources, every interval.
+// When one of them changed since the last load the configuration is decoded into a fresh struct and the OnChange
+// callbacks are notified. Watch blocks until ctx is done and requires a prior LoadConfig call.
+func (c *Config) Watch(ctx context.Context, interval time.Duration) error {
+	if c.Current() == nil {
+		return fmt.Errorf("config: watch before load")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("config: invalid watch interval %s", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var attempted []fileState
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			c.mu.RLock()
+			loaded := c.states
+			c.mu.RUnlock()
+
+			// A failed reload is retried only once the files change again.
+			current := statFiles(c.watchedFiles())
+			if reflect.DeepEqual(loaded, current) || reflect.DeepEqual(attempted, current) {
+				continue
+			}
+			attempted = current
+			c.Reload()
+		}
+	}
+}
+
+// Reload decodes the configuration into a fresh struct and notifies the registered callbacks.
+func (c *Config) Reload() {
+	c.mu.RLock()
+	old, template := c.parsedConfig, c.template
+	c.mu.RUnlock()
+
+	if old == nil {
+		c.reloadFailed(fmt.Errorf("config: reload before load"))
+		return
+	}
+
+	updated := reflect.New(reflect.TypeOf(old).Elem()).Interface()
+	if err := c.LoadConfig(updated, template); err != nil {
+		c.reloadFailed(err)
+		return
+	}
+
+	c.mu.RLock()
+	callbacks := make([]ChangeFunc, len(c.onChange))
+	copy(callbacks, c.onChange)
+	c.mu.RUnlock()
+
+	for _, fn := range callbacks {
+		fn(old, updated)
+	}
+}
+
+func (c *Config) reloadFailed(err error) {
+	c.mu.RLock()
+	callbacks := make([]func(error), len(c.onError))
+	copy(callbacks, c.onError)
+	c.mu.RUnlock()
+
+	for _, fn := range callbacks {
+		fn(err)
+	}
+}
+
+// watchedFiles returns the files read by the configuration sources.
+func (c *Config) watchedFiles() []string {
+	files := make([]string, 0, len(c.layers))
+	for _, l := range c.layers {
+		if l.src.Filename != "" {
+			files = append(files, l.src.Filename)
+		}
+	}
+
+	return files
+}
+
+func statFiles(files []string) []fileState {
+	states := make([]fileState, len(files))
+	for i, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		states[i] = fileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+	}
+
+	return states
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, filename, content string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	require.NoError(t, os.Chtimes(filename, modTime, modTime))
+}
+
+func TestConfig_Watch(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	now := time.Now()
+	writeConfig(t, filename, "app:\n    name: first\n", now)
+
+	cfg, err := WithFile(filename)
+	require.NoError(t, err)
+
+	setting := testConfig{}
+	require.NoError(t, cfg.LoadConfig(&setting, nil))
+
+	changes := make(chan [2]*testConfig, 1)
+	errs := make(chan error, 1)
+	cfg.OnChange(func(old, updated interface{}) {
+		changes <- [2]*testConfig{old.(*testConfig), updated.(*testConfig)}
+	})
+	cfg.OnReloadError(func(err error) {
+		errs <- err
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- cfg.Watch(ctx, 5*time.Millisecond)
+	}()
+
+	writeConfig(t, filename, "app:\n    name: second\n    port: 9090\n", now.Add(time.Second))
+	select {
+	case change := <-changes:
+		require.Equal(t, "first", change[0].App.Name)
+		require.Equal(t, "second", change[1].App.Name)
+		require.Equal(t, 9090, change[1].App.Port)
+		require.Equal(t, "us-west-1", change[1].Region)
+		require.Same(t, change[1], cfg.Current())
+	case <-time.After(time.Second):
+		require.Fail(t, "no change notification")
+	}
+
+	writeConfig(t, filename, "app:\n    port: invalid\n", now.Add(2*time.Second))
+	select {
+	case err = <-errs:
+		var tErr *TypeMismatchError
+		require.ErrorAs(t, err, &tErr)
+		require.Equal(t, filename, tErr.Filename)
+		require.Equal(t, 2, tErr.Line)
+		require.Equal(t, "second", cfg.Current().(*testConfig).App.Name)
+	case <-changes:
+		require.Fail(t, "invalid config must not be notified")
+	case <-time.After(time.Second):
+		require.Fail(t, "no error notification")
+	}
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestConfig_WatchErrors(t *testing.T) {
+	cfg, err := WithFile("testdata/config.test.yaml")
+	require.NoError(t, err)
+	require.Error(t, cfg.Watch(context.Background(), time.Second))
+
+	require.NoError(t, cfg.LoadConfig(&testConfig{}, nil))
+	require.Error(t, cfg.Watch(context.Background(), 0))
+}